@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+)
+
+// Represents a post or queue that can be approved
+type approvalTarget struct {
+	source config.Source
+	// Returns the content a signature of the given approval covers
+	signedContent func(config.Approval) ([]byte, error)
+}
+
+// Handles the approve subcommand, recording approvals in the config files
+func runApprove(args []string) error {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	by := fs.String("by", os.Getenv("USER"), "Name recorded as approved_by (default: $USER)")
+	keyPath := fs.String("key", "", "Ed25519 private key (PEM) to sign the approval with")
+	signaturesPath := fs.String("signatures", "", "Signatures file with the trusted approval keys (default: $"+signaturesEnv+")")
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		showApproveUsage()
+		return fmt.Errorf("config file and at least one post ID or queue name are required")
+	}
+	if *by == "" {
+		return fmt.Errorf("-by is required when $USER is not set")
+	}
+
+	var key ed25519.PrivateKey
+	if *keyPath != "" {
+		data, err := os.ReadFile(*keyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+		if key, err = config.ParsePrivateKey(data); err != nil {
+			return fmt.Errorf("%s: %w", *keyPath, err)
+		}
+	}
+
+	configPath := fs.Arg(0)
+	now := time.Now().Truncate(time.Second)
+	for _, id := range fs.Args()[1:] {
+		// Each edit moves the entries after it, so positions are read afresh
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := applySignatures(cfg, *signaturesPath); err != nil {
+			return err
+		}
+		target, err := findApprovalTarget(cfg, id)
+		if err != nil {
+			return err
+		}
+
+		approval := config.Approval{Status: config.StatusApproved, ApprovedBy: *by, ApprovedAt: now}
+		// A new approval makes an earlier signature invalid, so it is removed
+		var signature interface{}
+		if key != nil {
+			if err := checkSigningKey(cfg, *by, key); err != nil {
+				return err
+			}
+			signed, err := target.signedContent(approval)
+			if err != nil {
+				return fmt.Errorf("failed to sign %s: %w", id, err)
+			}
+			signature = config.Sign(key, signed)
+		}
+
+		err = config.SetFields(target.source, []config.Field{
+			{Key: "status", Value: approval.Status},
+			{Key: "approved_by", Value: approval.ApprovedBy},
+			{Key: "approved_at", Value: approval.ApprovedAt},
+			{Key: "signature", Value: signature},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to approve %s: %w", id, err)
+		}
+		if key != nil {
+			fmt.Printf("Approved and signed %s by %s at %s\n", id, *by, target.source)
+		} else {
+			fmt.Printf("Approved %s by %s at %s\n", id, *by, target.source)
+		}
+	}
+	return nil
+}
+
+// Returns the post, queue or calendar_posts entry with the given ID, name or file
+func findApprovalTarget(cfg *config.Config, id string) (approvalTarget, error) {
+	for _, post := range cfg.Posts {
+		if post.Identifier() != id {
+			continue
+		}
+		// Calendar posts are positioned at their .ics file
+		if post.Source.Line == 0 {
+			return approvalTarget{}, fmt.Errorf("post %s is generated from %s; approve its calendar_posts entry %s instead",
+				id, post.Source.File, post.Calendar)
+		}
+		return approvalTarget{
+			source: post.Source,
+			signedContent: func(approval config.Approval) ([]byte, error) {
+				post.Approval = approval
+				return cfg.SignedContent(post)
+			},
+		}, nil
+	}
+	for _, queue := range cfg.Queues {
+		if queue.Name == id {
+			return approvalTarget{
+				source: queue.Source(),
+				signedContent: func(approval config.Approval) ([]byte, error) {
+					queue.Approval = approval
+					return cfg.SignedQueueContent(queue)
+				},
+			}, nil
+		}
+	}
+	for _, source := range cfg.CalendarPosts {
+		if source.File == id {
+			return approvalTarget{
+				source: source.Source(),
+				signedContent: func(approval config.Approval) ([]byte, error) {
+					source.Approval = approval
+					return cfg.SignedCalendarContent(source)
+				},
+			}, nil
+		}
+	}
+	return approvalTarget{}, fmt.Errorf("no post, queue or calendar_posts file %s", id)
+}
+
+// Checks that signatures made with key will verify against the configured key of the approver
+func checkSigningKey(cfg *config.Config, by string, key ed25519.PrivateKey) error {
+	public := config.EncodePublicKey(key.Public().(ed25519.PublicKey))
+	if cfg.Signatures == nil {
+		return fmt.Errorf("no signatures keys configured; add %s: %s to signatures.keys", by, public)
+	}
+	encoded, ok := cfg.Signatures.Keys[by]
+	if !ok {
+		return fmt.Errorf("%s has no key in signatures.keys; add %s: %s", by, by, public)
+	}
+	configured, err := config.ParsePublicKey(encoded)
+	if err != nil {
+		return fmt.Errorf("signatures.keys.%s: %w", by, err)
+	}
+	if !configured.Equal(key.Public()) {
+		return fmt.Errorf("key does not match signatures.keys.%s", by)
+	}
+	return nil
+}
+
+func showApproveUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: x-scheduler approve [-by name] [-key private.pem] [-signatures file] <config.yaml> <post-id|queue-name|calendar-file>...\n")
+}
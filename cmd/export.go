@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/export"
+	"github.com/zinrai/x-scheduler/internal/state"
+	"github.com/zinrai/x-scheduler/pkg/logger"
+)
+
+// Path of the feed when serving over HTTP
+const feedPath = "/calendar.ics"
+
+// Number of days of queue slots exported by default
+const defaultQueueDays = 14
+
+// Represents the inputs of the exported feed
+type exportOptions struct {
+	configPath string
+	statePath  string
+	days       int
+}
+
+// Handles the export-ics subcommand
+func runExportICS(args []string) error {
+	fs := flag.NewFlagSet("export-ics", flag.ExitOnError)
+	output := fs.String("o", "", "Write the feed to this file (default: stdout)")
+	serve := fs.String("serve", "", "Serve the feed over HTTP on this address, e.g. localhost:8080")
+	stateFlag := fs.String("state", "", "Path to run state file, for queue progress (default: next to config)")
+	days := fs.Int("days", defaultQueueDays, "Days of queue slots to export")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		showExportUsage()
+		return fmt.Errorf("config file path is required")
+	}
+	if *days < 0 {
+		return fmt.Errorf("-days must not be negative")
+	}
+	opts := exportOptions{configPath: fs.Arg(0), statePath: *stateFlag, days: *days}
+	if opts.statePath == "" {
+		opts.statePath = defaultStatePath(opts.configPath)
+	}
+
+	if *serve != "" {
+		return serveICS(opts, *serve)
+	}
+
+	feed, err := buildICS(opts)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(feed)
+		return err
+	}
+	if err := os.WriteFile(*output, feed, 0o644); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	fmt.Printf("Exported schedule to %s\n", *output)
+	return nil
+}
+
+// Loads and validates the config and renders the iCalendar feed
+func buildICS(opts exportOptions) ([]byte, error) {
+	cfg, err := config.Load(opts.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	// Queue progress decides which items fill the upcoming slots
+	store, err := state.Open(opts.statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var buf bytes.Buffer
+	if err := export.WriteICS(&buf, export.PlannedPosts(cfg, store, now, opts.days), now); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Serves the feed, reloading the config on every request so edits show up
+func serveICS(opts exportOptions, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(feedPath, func(w http.ResponseWriter, r *http.Request) {
+		feed, err := buildICS(opts)
+		if err != nil {
+			logger.Error("Failed to build feed: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write(feed)
+	})
+
+	logger.Info("Serving schedule at http://%s%s", addr, feedPath)
+	return http.ListenAndServe(addr, mux)
+}
+
+func showExportUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: x-scheduler export-ics [-o file] [-serve addr] [-state file] [-days n] <config.yaml>\n")
+}
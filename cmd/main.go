@@ -4,11 +4,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"time"
+	"path/filepath"
 
 	"github.com/zinrai/x-scheduler/internal/config"
 	"github.com/zinrai/x-scheduler/internal/executor"
-	"github.com/zinrai/x-scheduler/internal/poster"
+	"github.com/zinrai/x-scheduler/internal/state"
 	"github.com/zinrai/x-scheduler/pkg/logger"
 )
 
@@ -16,13 +16,29 @@ const (
 	Version = "0.2.0"
 )
 
+// Name of the run state file created next to the configuration
+const defaultStateFile = ".x-scheduler.state.json"
+
+// Environment variable naming the signatures file, if -signatures is not given
+const signaturesEnv = "X_SCHEDULER_SIGNATURES"
+
 func main() {
+	// Dispatch subcommands before parsing operation flags
+	if len(os.Args) > 1 && runSubcommand(os.Args[1], os.Args[2:]) {
+		os.Exit(0)
+	}
+
 	var (
 		executeFlag  = flag.Bool("execute", false, "Execute posts scheduled for today")
 		validateFlag = flag.Bool("validate", false, "Validate configuration file")
 		versionFlag  = flag.Bool("version", false, "Show version information")
 		verboseFlag  = flag.Bool("verbose", false, "Enable verbose logging")
 		helpFlag     = flag.Bool("help", false, "Show help information")
+		stateFlag    = flag.String("state", "", "Path to run state file (default: next to config)")
+		formatFlag   = flag.String("format", formatText, "Output format of -validate: text, json or github")
+		failOnFlag   = flag.String("fail-on", string(config.SeverityError), "Lowest finding severity that fails -validate: error or warning")
+		linksFlag    = flag.Bool("check-links", false, "Check that links in upcoming posts respond during -validate")
+		sigsFlag     = flag.String("signatures", "", "Signatures file with the trusted approval keys (default: $"+signaturesEnv+")")
 	)
 
 	flag.Parse()
@@ -48,9 +64,51 @@ func main() {
 	configPath := validateFlagsAndGetConfigPath(*executeFlag, *validateFlag)
 
 	// Execute the requested operation
-	if err := runOperation(*executeFlag, *validateFlag, configPath); err != nil {
+	statePath := *stateFlag
+	if statePath == "" {
+		statePath = defaultStatePath(configPath)
+	}
+
+	opts := validateOptions{format: *formatFlag, failOn: config.Severity(*failOnFlag), checkLinks: *linksFlag}
+	if err := opts.check(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		showUsage()
+		os.Exit(1)
+	}
+
+	if err := runOperation(*executeFlag, *validateFlag, configPath, statePath, *sigsFlag, opts); err != nil {
+		logger.Fatal("Operation failed: %v", err)
+	}
+}
+
+// Runs the named subcommand, returning false if name is not a subcommand
+func runSubcommand(name string, args []string) bool {
+	var err error
+	switch name {
+	case "state":
+		err = runStateCommand(args)
+	case "export-ics":
+		err = runExportICS(args)
+	case "schema":
+		err = runSchema(args)
+	case "approve":
+		err = runApprove(args)
+	default:
+		return false
+	}
+
+	if err != nil {
 		logger.Fatal("Operation failed: %v", err)
 	}
+	return true
+}
+
+// Returns the run state file path used for the given config file, directory or glob
+func defaultStatePath(configPath string) string {
+	if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+		return filepath.Join(configPath, defaultStateFile)
+	}
+	return filepath.Join(filepath.Dir(configPath), defaultStateFile)
 }
 
 // Validates command line flags and returns config path
@@ -97,93 +155,115 @@ func getConfigFilePath() string {
 	return args[0]
 }
 
-func runOperation(execute, validate bool, configPath string) error {
+func runOperation(execute, validate bool, configPath, statePath, signaturesPath string, opts validateOptions) error {
 	// Load configuration
 	cfg, err := config.Load(configPath)
+	if err == nil {
+		if err := applySignatures(cfg, signaturesPath); err != nil {
+			return err
+		}
+	}
+
+	if validate {
+		return runValidate(cfg, err, configPath, statePath, opts)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Validate configuration
-	if err := cfg.Validate(); err != nil {
+	// Lint errors only hold back the posts they are about, which Plan drops
+	findings := cfg.Check()
+	if err := findings.WithoutLint().Err(); err != nil {
 		return fmt.Errorf("config validation failed: %w", err)
 	}
+	logFindings(findings)
 
-	switch {
-	case validate:
-		return runValidate(cfg, configPath)
-	case execute:
-		return runExecute(cfg, configPath)
-	default:
-		return fmt.Errorf("no operation specified")
+	if execute {
+		return runExecute(cfg, statePath)
 	}
+	return fmt.Errorf("no operation specified")
 }
 
-func runValidate(cfg *config.Config, configPath string) error {
-	logger.Info("Validating configuration: %s", configPath)
-
-	enabledPosts := cfg.GetEnabledPosts()
-	futurePosts := executor.FilterFuturePosts(cfg.Posts, time.Now())
-
-	fmt.Printf("Configuration validation successful\n")
-	fmt.Printf("Total posts: %d\n", len(cfg.Posts))
-	fmt.Printf("Enabled posts: %d\n", len(enabledPosts))
-	fmt.Printf("Future posts for today: %d\n", len(futurePosts))
-
-	// Check poster (xurl) availability
-	if err := poster.Validate(); err != nil {
-		fmt.Printf("Warning: Poster validation failed: %v\n", err)
-		fmt.Printf("Make sure xurl is installed and configured properly\n")
-	} else {
-		fmt.Printf("Poster: xurl command available\n")
+// Replaces the config's signature settings with those of the signatures file
+// given by path or $X_SCHEDULER_SIGNATURES, if any
+//
+// Keys in the config can be changed by anyone who can edit it, so a file
+// outside the config tree is the only way to trust them.
+func applySignatures(cfg *config.Config, path string) error {
+	if path == "" {
+		path = os.Getenv(signaturesEnv)
 	}
-
-	if len(futurePosts) > 0 {
-		showUpcomingPosts(futurePosts)
+	if path == "" {
+		return nil
 	}
 
+	signatures, err := config.LoadSignatures(path)
+	if err != nil {
+		return err
+	}
+	if cfg.Signatures != nil {
+		logger.Warn("Ignoring signatures in the config in favor of %s", path)
+	}
+	cfg.Signatures = signatures
 	return nil
 }
 
-// Displays upcoming posts information
-func showUpcomingPosts(futurePosts []config.Post) {
-	fmt.Printf("\nUpcoming posts for today:\n")
-	for i, post := range futurePosts {
-		if i >= 5 { // Show only first 5
-			fmt.Printf("... and %d more\n", len(futurePosts)-5)
-			break
-		}
-		if post.Test {
-			fmt.Printf("  [TEST] %s\n", truncateContent(post.Content, 50))
-		} else {
-			fmt.Printf("  %s: %s\n",
-				post.ScheduledAt.Format("15:04"),
-				truncateContent(post.Content, 50))
+// Logs warnings and informational findings
+func logFindings(findings config.Findings) {
+	for _, f := range findings {
+		switch f.Severity {
+		case config.SeverityWarning:
+			logger.Warn("%s", f)
+		case config.SeverityInfo:
+			logger.Info("%s", f)
 		}
 	}
 }
 
-func runExecute(cfg *config.Config, configPath string) error {
+func runExecute(cfg *config.Config, statePath string) error {
 	logger.Info("Executing posts scheduled for today")
 
+	// Open run state to avoid duplicate posts across invocations
+	store, err := state.Open(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to open run state: %w", err)
+	}
+	logger.Debug("Using run state file: %s", statePath)
+
 	// Create executor and execute posts
-	exec := executor.NewExecutor()
+	exec := executor.NewExecutor(store)
 	return exec.Execute(cfg)
 }
 
 func showHelp() {
 	fmt.Printf("x-scheduler - X (Twitter) post scheduler\n\n")
 	fmt.Printf("USAGE:\n")
-	fmt.Printf("  x-scheduler [flags] <config.yaml>\n\n")
+	fmt.Printf("  x-scheduler [flags] <config.yaml>\n")
+	fmt.Printf("  x-scheduler state <list|prune> [flags] <config.yaml>\n")
+	fmt.Printf("  x-scheduler export-ics [-o file] [-serve addr] [-state file] [-days n] <config.yaml>\n")
+	fmt.Printf("  x-scheduler schema [-o file]\n")
+	fmt.Printf("  x-scheduler approve [-by name] [-key private.pem] [-signatures file] <config.yaml> <post-id|queue-name|calendar-file>...\n\n")
 	fmt.Printf("FLAGS:\n")
-	fmt.Printf("  -execute    Execute posts scheduled for today\n")
-	fmt.Printf("  -validate   Validate configuration file\n")
-	fmt.Printf("  -verbose    Enable verbose logging\n")
-	fmt.Printf("  -version    Show version information\n")
-	fmt.Printf("  -help       Show this help message\n\n")
+	fmt.Printf("  -execute      Execute posts scheduled for today\n")
+	fmt.Printf("  -validate     Validate configuration file\n")
+	fmt.Printf("  -state        Path to run state file (default: %s next to config)\n", defaultStateFile)
+	fmt.Printf("  -format       Output format of -validate: text, json or github (default: text)\n")
+	fmt.Printf("  -fail-on      Lowest severity that fails -validate: error or warning (default: error)\n")
+	fmt.Printf("  -check-links  Check that links in upcoming posts respond during -validate\n")
+	fmt.Printf("  -signatures   Signatures file with the trusted approval keys (default: $%s)\n", signaturesEnv)
+	fmt.Printf("  -verbose      Enable verbose logging\n")
+	fmt.Printf("  -version      Show version information\n")
+	fmt.Printf("  -help         Show this help message\n\n")
 	fmt.Printf("EXAMPLES:\n")
 	fmt.Printf("  x-scheduler -validate config.yaml\n")
-	fmt.Printf("  x-scheduler -execute config.yaml\n\n")
+	fmt.Printf("  x-scheduler -validate -format json -fail-on warning config.yaml\n")
+	fmt.Printf("  x-scheduler -validate -check-links config.yaml\n")
+	fmt.Printf("  x-scheduler -execute config.yaml\n")
+	fmt.Printf("  x-scheduler state list config.yaml\n")
+	fmt.Printf("  x-scheduler state prune -older-than 720h config.yaml\n")
+	fmt.Printf("  x-scheduler export-ics -o schedule.ics config.yaml\n")
+	fmt.Printf("  x-scheduler approve -by alice -key alice.pem config.yaml launch-post\n\n")
 	fmt.Printf("SCHEDULING:\n")
 	fmt.Printf("  Run daily via cron to process scheduled posts:\n")
 	fmt.Printf("  0 0 * * * /usr/local/bin/x-scheduler -execute /path/to/config.yaml\n\n")
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zinrai/x-scheduler/internal/schema"
+)
+
+// Handles the schema subcommand
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := fs.String("o", "", "Write the schema to this file (default: stdout)")
+	generate := fs.String("generate", "", "Regenerate the schema from the config package source in this directory")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		showSchemaUsage()
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	data := schema.JSON
+	if *generate != "" {
+		var err error
+		if data, err = schema.Generate(*generate); err != nil {
+			return fmt.Errorf("failed to generate schema: %w", err)
+		}
+	}
+
+	if *output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
+
+func showSchemaUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: x-scheduler schema [-o file]\n")
+	fmt.Fprintf(os.Stderr, "       x-scheduler schema -generate internal/config [-o file]\n")
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+// Handles the state subcommand
+func runStateCommand(args []string) error {
+	if len(args) == 0 {
+		showStateUsage()
+		return fmt.Errorf("state command is required")
+	}
+
+	switch args[0] {
+	case "list":
+		return runStateList(args[1:])
+	case "prune":
+		return runStatePrune(args[1:])
+	default:
+		showStateUsage()
+		return fmt.Errorf("unknown state command: %s", args[0])
+	}
+}
+
+// Shows recorded posting attempts
+func runStateList(args []string) error {
+	fs := flag.NewFlagSet("state list", flag.ExitOnError)
+	stateFlag := fs.String("state", "", "Path to run state file (default: next to config)")
+	fs.Parse(args)
+
+	statePath, err := resolveStatePath(*stateFlag, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	store, err := state.Open(statePath)
+	if err != nil {
+		return err
+	}
+
+	records := store.Records()
+	if len(records) == 0 {
+		fmt.Printf("No recorded attempts in %s\n", statePath)
+		return nil
+	}
+
+	fmt.Printf("Run state: %s (%d posts)\n\n", statePath, len(records))
+	for _, record := range records {
+		last, _ := record.LastAttempt()
+		fmt.Printf("%s  %s  %-8s attempts=%d",
+			record.Key,
+			record.ScheduledAt.Local().Format("2006-01-02 15:04"),
+			last.Outcome,
+			len(record.Attempts))
+		if ids := record.TweetIDs(); len(ids) > 0 {
+			fmt.Printf(" tweets=%s", strings.Join(ids, ","))
+		}
+		fmt.Printf("\n    %s\n", record.Summary)
+		if last.Error != "" {
+			fmt.Printf("    last error: %s\n", truncateContent(last.Error, 100))
+		}
+	}
+
+	return nil
+}
+
+// Removes records from the run state
+func runStatePrune(args []string) error {
+	fs := flag.NewFlagSet("state prune", flag.ExitOnError)
+	stateFlag := fs.String("state", "", "Path to run state file (default: next to config)")
+	olderThan := fs.Duration("older-than", 0, "Remove records whose last attempt is older than this duration")
+	key := fs.String("key", "", "Remove the record with this key")
+	failed := fs.Bool("failed", false, "Remove records that were never posted successfully")
+	orphaned := fs.Bool("orphaned", false, "Remove records of posts no longer in the config")
+	fs.Parse(args)
+
+	if *olderThan == 0 && *key == "" && !*failed && !*orphaned {
+		return fmt.Errorf("one of -older-than, -key, -failed or -orphaned is required")
+	}
+
+	statePath, err := resolveStatePath(*stateFlag, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	store, err := state.Open(statePath)
+	if err != nil {
+		return err
+	}
+
+	// Orphan detection needs the current set of post keys
+	var known map[string]bool
+	if *orphaned {
+		if fs.NArg() != 1 {
+			return fmt.Errorf("-orphaned requires the config file path")
+		}
+		cfg, err := config.Load(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		known = make(map[string]bool)
+		for _, post := range cfg.Posts {
+			known[post.Identifier()] = true
+		}
+	}
+
+	cutoff := time.Now().Add(-*olderThan)
+	removed, err := store.Prune(func(record state.Record) bool {
+		if *key != "" && record.Key == *key {
+			return true
+		}
+		if *failed && !record.Posted() {
+			return true
+		}
+		if *orphaned && !known[record.Key] {
+			return true
+		}
+		if *olderThan > 0 {
+			last, ok := record.LastAttempt()
+			return !ok || last.At.Before(cutoff)
+		}
+		return false
+	})
+	if err != nil {
+		return err
+	}
+
+	if *key != "" && removed == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no record found for key %s\n", *key)
+	}
+
+	fmt.Printf("Removed %d records from %s\n", removed, statePath)
+	return nil
+}
+
+// Returns the state file path from the -state flag or the config path
+func resolveStatePath(stateFlag string, args []string) (string, error) {
+	if stateFlag != "" {
+		return stateFlag, nil
+	}
+	if len(args) != 1 {
+		showStateUsage()
+		return "", fmt.Errorf("config file path or -state is required")
+	}
+	return defaultStatePath(args[0]), nil
+}
+
+func showStateUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: x-scheduler state list [-state file] <config.yaml>\n")
+	fmt.Fprintf(os.Stderr, "       x-scheduler state prune [-state file] [-older-than d] [-key k] [-failed] [-orphaned] <config.yaml>\n")
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/content"
+	"github.com/zinrai/x-scheduler/internal/executor"
+	"github.com/zinrai/x-scheduler/internal/linkcheck"
+	"github.com/zinrai/x-scheduler/internal/poster"
+	"github.com/zinrai/x-scheduler/internal/state"
+	"github.com/zinrai/x-scheduler/pkg/logger"
+)
+
+// Output formats of -validate
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatGitHub = "github" // GitHub Actions workflow annotations
+)
+
+// Controls how -validate reports and when it fails
+type validateOptions struct {
+	format string
+	failOn config.Severity // Lowest severity that makes validation fail
+
+	checkLinks bool // Request every link in upcoming posts
+}
+
+// Checks the options for errors
+func (o validateOptions) check() error {
+	switch o.format {
+	case formatText, formatJSON, formatGitHub:
+	default:
+		return fmt.Errorf("unknown format %q (want text, json or github)", o.format)
+	}
+	switch o.failOn {
+	case config.SeverityError, config.SeverityWarning:
+	default:
+		return fmt.Errorf("unknown -fail-on %q (want error or warning)", o.failOn)
+	}
+	return nil
+}
+
+// Represents the result of -validate
+type validationReport struct {
+	Config     string          `json:"config"`
+	Valid      bool            `json:"valid"`
+	Errors     int             `json:"errors"`
+	Warnings   int             `json:"warnings"`
+	Findings   []reportFinding `json:"findings"`
+	Plan       []reportPost    `json:"plan"`
+	Suppressed []reportPost    `json:"suppressed"`
+	BlackedOut []reportPost    `json:"blacked_out"`
+	Summary    map[string]int  `json:"summary,omitempty"`
+
+	PosterAvailable bool `json:"poster_available"`
+}
+
+// Represents a finding in the JSON report
+type reportFinding struct {
+	Severity config.Severity `json:"severity"`
+	File     string          `json:"file,omitempty"`
+	Line     int             `json:"line,omitempty"`
+	Column   int             `json:"column,omitempty"`
+	Message  string          `json:"message"`
+}
+
+// Represents a post in the JSON report
+type reportPost struct {
+	ID          string     `json:"id"`
+	Account     string     `json:"account,omitempty"`
+	Content     string     `json:"content"`
+	Lang        string     `json:"lang,omitempty"`
+	Variant     int        `json:"variant,omitempty"`
+	Thread      []string   `json:"thread,omitempty"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	ExecuteAt   *time.Time `json:"execute_at,omitempty"`
+	Test        bool       `json:"test,omitempty"`
+	Adjustments []string   `json:"adjustments,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+}
+
+// Validates the loaded config, prints the report and applies the exit policy
+//
+// A config that failed to load because of problems in its files is reported
+// like any other invalid config.
+func runValidate(cfg *config.Config, loadErr error, configPath, statePath string, opts validateOptions) error {
+	logger.Info("Validating configuration: %s", configPath)
+
+	var findings config.Findings
+	var loadProblems config.ValidationErrors
+	switch {
+	case errors.As(loadErr, &loadProblems):
+		findings = config.Findings(loadProblems)
+	case loadErr != nil:
+		return fmt.Errorf("failed to load config: %w", loadErr)
+	default:
+		findings = cfg.Check()
+	}
+
+	report := validationReport{
+		Config:     configPath,
+		Findings:   []reportFinding{},
+		Plan:       []reportPost{},
+		Suppressed: []reportPost{},
+		BlackedOut: []reportPost{},
+	}
+
+	var futurePosts []executor.ScheduledPost
+	var suppressed []executor.SuppressedPost
+	var blackedOut []config.BlackoutMatch
+	if findings.Count(config.SeverityError) == 0 {
+		// Run state is only read, so validation never records attempts
+		store, err := state.Open(statePath)
+		if err != nil {
+			return fmt.Errorf("failed to open run state: %w", err)
+		}
+		futurePosts, suppressed = executor.NewExecutor(store).Plan(cfg)
+		findings = append(findings, queueFindings(cfg, store)...)
+		blackedOut = cfg.BlackedOutPosts(time.Now())
+
+		// Check poster (xurl) availability
+		if err := poster.Validate(); err != nil {
+			findings = append(findings, config.Finding{
+				Severity: config.SeverityWarning,
+				Message:  fmt.Sprintf("poster validation failed, make sure xurl is installed and configured properly: %v", err),
+			})
+		} else {
+			report.PosterAvailable = true
+		}
+
+		if opts.checkLinks {
+			findings = append(findings, linkFindings(cfg.Links(time.Now()), &linkcheck.Checker{})...)
+		}
+
+		report.Summary = map[string]int{
+			"total_posts":   len(cfg.Posts),
+			"enabled_posts": len(cfg.GetEnabledPosts()),
+			"future_today":  len(futurePosts),
+		}
+	}
+
+	report.Errors = findings.Count(config.SeverityError)
+	report.Warnings = findings.Count(config.SeverityWarning)
+	report.Valid = report.Errors == 0
+	for _, f := range findings {
+		report.Findings = append(report.Findings, reportFinding{
+			Severity: f.Severity,
+			File:     f.Source.File,
+			Line:     f.Source.Line,
+			Column:   f.Source.Column,
+			Message:  f.Message,
+		})
+	}
+	for _, sp := range futurePosts {
+		report.Plan = append(report.Plan, reportPost{
+			ID:          sp.Post.Identifier(),
+			Account:     sp.Post.Account,
+			Content:     sp.Content,
+			Lang:        sp.Post.Lang,
+			Variant:     sp.Variant,
+			Thread:      sp.Thread,
+			ScheduledAt: sp.Post.ScheduledAt,
+			ExecuteAt:   timePtr(sp.ExecuteAt),
+			Test:        sp.Post.Test,
+			Adjustments: sp.Adjustments,
+		})
+	}
+	for _, sp := range suppressed {
+		report.Suppressed = append(report.Suppressed, reportPost{
+			ID:          sp.Post.Identifier(),
+			Account:     sp.Post.Account,
+			Content:     sp.Post.Content,
+			ScheduledAt: sp.Post.ScheduledAt,
+			ExecuteAt:   timePtr(sp.At),
+			Reason:      sp.Reason,
+		})
+	}
+	for _, match := range blackedOut {
+		report.BlackedOut = append(report.BlackedOut, reportPost{
+			ID:          match.Post.Identifier(),
+			Account:     match.Post.Account,
+			Content:     match.Post.Content,
+			ScheduledAt: match.Post.ScheduledAt,
+			Reason:      match.Blackout.String(),
+		})
+	}
+
+	switch opts.format {
+	case formatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	case formatGitHub:
+		showAnnotations(findings)
+		if report.Valid {
+			showReport(report, futurePosts, suppressed, blackedOut)
+		}
+	default:
+		showFindings(findings)
+		if report.Valid {
+			showReport(report, futurePosts, suppressed, blackedOut)
+		}
+	}
+
+	return validationOutcome(findings, opts.failOn)
+}
+
+// Returns the progress of the enabled queues, warning about queues that ran dry
+func queueFindings(cfg *config.Config, store *state.Store) config.Findings {
+	var findings config.Findings
+	for _, queue := range cfg.Queues {
+		if !queue.Enabled {
+			continue
+		}
+		remaining := executor.RemainingQueueItems(queue, store.Rotation(executor.QueueKey(queue.Name)))
+		finding := config.Finding{
+			Severity: config.SeverityInfo,
+			Source:   queue.Source(),
+			Message:  fmt.Sprintf("queue %s has %d of %d items left", queue.Name, remaining, len(queue.Items)),
+		}
+		if remaining == 0 {
+			if queue.WhenEmptyOrDefault() == config.QueueRecycle {
+				finding.Message = fmt.Sprintf("queue %s is recycling its %d items", queue.Name, len(queue.Items))
+			} else {
+				finding.Severity = config.SeverityWarning
+				finding.Message = fmt.Sprintf("queue %s has run out of items and will skip its slots", queue.Name)
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// Returns a pointer to a copy of t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// Checks the links of upcoming posts, reporting problems at every post that uses the link
+//
+// Broken links and TLS errors are warnings; redirects are informational.
+func linkFindings(links []config.Link, checker *linkcheck.Checker) config.Findings {
+	var urls []string
+	index := make(map[string]int)
+	for _, link := range links {
+		if _, ok := index[link.URL]; !ok {
+			index[link.URL] = len(urls)
+			urls = append(urls, link.URL)
+		}
+	}
+	logger.Info("Checking %d links", len(urls))
+	results := checker.Check(context.Background(), urls)
+
+	var findings config.Findings
+	for _, link := range links {
+		result := results[index[link.URL]]
+		if result.OK() {
+			continue
+		}
+		severity := config.SeverityWarning
+		if result.Redirect() {
+			severity = config.SeverityInfo
+		}
+		findings = append(findings, config.Finding{
+			Severity: severity,
+			Source:   link.Source,
+			Message:  fmt.Sprintf("%s: link %s %s", link.Label, link.URL, result),
+		})
+	}
+	return findings
+}
+
+// Returns an error if the findings fail validation under the exit policy
+func validationOutcome(findings config.Findings, failOn config.Severity) error {
+	if errs := findings.Count(config.SeverityError); errs > 0 {
+		return fmt.Errorf("config validation failed: %d errors", errs)
+	}
+	if warnings := findings.Count(config.SeverityWarning); failOn == config.SeverityWarning && warnings > 0 {
+		return fmt.Errorf("config validation failed: %d warnings", warnings)
+	}
+	return nil
+}
+
+// Prints findings as text, one per line
+func showFindings(findings config.Findings) {
+	for _, f := range findings {
+		label := strings.ToUpper(string(f.Severity[:1])) + string(f.Severity[1:])
+		fmt.Printf("%s: %s\n", label, f.Error())
+	}
+}
+
+// Prints findings as GitHub Actions workflow commands
+func showAnnotations(findings config.Findings) {
+	commands := map[config.Severity]string{
+		config.SeverityError:   "error",
+		config.SeverityWarning: "warning",
+		config.SeverityInfo:    "notice",
+	}
+	escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+	for _, f := range findings {
+		var properties []string
+		if f.Source.File != "" {
+			properties = append(properties, "file="+escapeProperty.Replace(f.Source.File))
+		}
+		if f.Source.Line > 0 {
+			properties = append(properties, fmt.Sprintf("line=%d", f.Source.Line))
+		}
+		if f.Source.Column > 0 {
+			properties = append(properties, fmt.Sprintf("col=%d", f.Source.Column))
+		}
+		command := "::" + commands[f.Severity]
+		if len(properties) > 0 {
+			command += " " + strings.Join(properties, ",")
+		}
+		fmt.Printf("%s::%s\n", command, escape.Replace(f.Message))
+	}
+}
+
+// Prints the plan of a valid config as text
+func showReport(report validationReport, futurePosts []executor.ScheduledPost,
+	suppressed []executor.SuppressedPost, blackedOut []config.BlackoutMatch) {
+	fmt.Printf("Configuration validation successful\n")
+	fmt.Printf("Total posts: %d\n", report.Summary["total_posts"])
+	fmt.Printf("Enabled posts: %d\n", report.Summary["enabled_posts"])
+	fmt.Printf("Future posts for today: %d\n", report.Summary["future_today"])
+	if report.PosterAvailable {
+		fmt.Printf("Poster: xurl command available\n")
+	}
+
+	if len(futurePosts) > 0 {
+		showUpcomingPosts(futurePosts)
+	}
+
+	if len(suppressed) > 0 {
+		showSuppressedPosts(suppressed)
+	}
+
+	if len(blackedOut) > 0 {
+		showBlackedOutPosts(blackedOut)
+	}
+}
+
+// Displays upcoming posts information
+func showUpcomingPosts(futurePosts []executor.ScheduledPost) {
+	fmt.Printf("\nUpcoming posts for today:\n")
+	for i, scheduledPost := range futurePosts {
+		if i >= 5 { // Show only first 5
+			fmt.Printf("... and %d more\n", len(futurePosts)-5)
+			break
+		}
+		post := scheduledPost.Post
+		if post.Test {
+			fmt.Printf("  [TEST] %s: %s\n", post.Identifier(), truncateContent(scheduledPost.Content, 50))
+			continue
+		}
+
+		fmt.Printf("  %s %s: %s\n",
+			scheduledPost.ExecuteAt.Format("15:04:05"),
+			post.Identifier(),
+			truncateContent(scheduledPost.Content, 50))
+		// Content is truncated, but links are shown in full as they will be posted
+		for _, link := range content.URLs(scheduledPost.Content) {
+			fmt.Printf("           link %s\n", link)
+		}
+		if len(post.Localized) > 0 {
+			fmt.Printf("           language %s (of %s)\n", post.Lang, strings.Join(post.Languages(), ", "))
+		}
+		if post.Status == config.StatusApproved && post.Signature != "" {
+			fmt.Printf("           approved by %s (signed)\n", post.ApprovedBy)
+		} else if post.Status == config.StatusApproved {
+			fmt.Printf("           approved by %s\n", post.ApprovedBy)
+		}
+		if scheduledPost.Variant > 0 {
+			fmt.Printf("           variant %d of %d (%s)\n",
+				scheduledPost.Variant, len(post.Variants), post.RotationOrDefault())
+		}
+		for i, part := range scheduledPost.Thread {
+			fmt.Printf("           thread part %d of %d (%d characters):\n", i+1, len(scheduledPost.Thread), content.WeightedLength(part))
+			for _, line := range strings.Split(part, "\n") {
+				fmt.Printf("             %s\n", line)
+			}
+		}
+		if scheduledPost.JitterOffset != 0 {
+			fmt.Printf("           jitter %s from %s (seed %d)\n",
+				executor.FormatOffset(scheduledPost.JitterOffset),
+				post.ScheduledAt.In(scheduledPost.ExecuteAt.Location()).Format("15:04:05"),
+				scheduledPost.JitterSeed)
+		}
+		if len(scheduledPost.Adjustments) > 0 {
+			fmt.Printf("           original %s, adjusted %s\n",
+				post.ScheduledAt.In(scheduledPost.ExecuteAt.Location()).Format("15:04:05"),
+				scheduledPost.ExecuteAt.Format("15:04:05"))
+			for _, adjustment := range scheduledPost.Adjustments {
+				fmt.Printf("           %s\n", adjustment)
+			}
+		}
+	}
+}
+
+// Displays posts that are planned for today but will not be executed
+func showSuppressedPosts(suppressed []executor.SuppressedPost) {
+	fmt.Printf("\nSuppressed posts for today:\n")
+	for _, suppressedPost := range suppressed {
+		fmt.Printf("  %s %s: %s\n",
+			suppressedPost.At.Format("15:04:05"),
+			suppressedPost.Post.Identifier(),
+			truncateContent(suppressedPost.Post.Content, 50))
+		fmt.Printf("           %s\n", suppressedPost.Reason)
+	}
+}
+
+// Displays all upcoming posts scheduled within a blackout
+func showBlackedOutPosts(matches []config.BlackoutMatch) {
+	fmt.Printf("\nPosts scheduled within blackouts:\n")
+	for _, match := range matches {
+		fmt.Printf("  %s %s: %s\n",
+			match.Post.ScheduledAt.Local().Format("2006-01-02 15:04"),
+			match.Post.Identifier(),
+			truncateContent(match.Post.Content, 50))
+		fmt.Printf("           %s\n", match.Blackout)
+	}
+}
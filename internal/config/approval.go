@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Represents the review state of a post
+type Status string
+
+const (
+	StatusDraft    Status = "draft"     // Being written, never published
+	StatusInReview Status = "in_review" // Waiting for approval, never published
+	StatusApproved Status = "approved"  // May be published
+	StatusRejected Status = "rejected"  // Declined, never published
+)
+
+// Represents the review state and sign-off of a post, queue or calendar feed
+type Approval struct {
+	Status     Status    `yaml:"status,omitempty"`      // Review state; without one, posts publish unless require_approval is set
+	ApprovedBy string    `yaml:"approved_by,omitempty"` // Who approved the post
+	ApprovedAt time.Time `yaml:"approved_at,omitempty"` // When the post was approved
+	Signature  string    `yaml:"signature,omitempty"`   // Base64 Ed25519 signature of the approved content, written by approve -key
+}
+
+// Checks the approval settings for errors
+func (a Approval) Validate() error {
+	switch a.Status {
+	case "", StatusDraft, StatusInReview, StatusApproved, StatusRejected:
+	default:
+		return fmt.Errorf("unknown status %q (want draft, in_review, approved or rejected)", a.Status)
+	}
+	if a.Status == StatusApproved && a.ApprovedBy == "" {
+		return fmt.Errorf("status approved requires approved_by")
+	}
+	if a.Signature != "" && a.Status != StatusApproved {
+		return fmt.Errorf("signature requires status approved")
+	}
+	return nil
+}
+
+// Returns why the approval workflow holds the post back, or "" if it may be published
+//
+// Dry runs publish nothing, so they need no approval. With signature keys
+// configured, signed posts also need a valid signature, as any edit after
+// approval invalidates it.
+func (c *Config) ApprovalBlock(post Post) string {
+	requireSignature := c.Signatures != nil && c.Signatures.Require
+	switch {
+	case post.DryRun:
+		return ""
+	case post.Status != "" && post.Status != StatusApproved:
+		return fmt.Sprintf("not approved (status %s)", post.Status)
+	case post.Status == "" && (c.RequireApproval || requireSignature):
+		return "not approved (no status)"
+	case c.Signatures == nil:
+		return ""
+	case post.Signature != "":
+		if err := c.VerifySignature(post); err != nil {
+			return fmt.Sprintf("approval signature is invalid (%v)", err)
+		}
+	case requireSignature:
+		return "approval is not signed"
+	}
+	return ""
+}
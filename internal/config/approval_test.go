@@ -0,0 +1,131 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApproval_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		approval Approval
+		wantErr  string
+	}{
+		{name: "no status", approval: Approval{}},
+		{name: "draft", approval: Approval{Status: StatusDraft}},
+		{name: "approved", approval: Approval{Status: StatusApproved, ApprovedBy: "alice"}},
+		{
+			name:     "approved without approver",
+			approval: Approval{Status: StatusApproved},
+			wantErr:  "status approved requires approved_by",
+		},
+		{
+			name:     "unknown status",
+			approval: Approval{Status: "ready"},
+			wantErr:  `unknown status "ready" (want draft, in_review, approved or rejected)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.approval.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ApprovalBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		require bool
+		post    Post
+		want    string
+	}{
+		{name: "no status", post: Post{}, want: ""},
+		{name: "no status when required", require: true, post: Post{}, want: "not approved (no status)"},
+		{name: "approved", require: true, post: Post{Approval: Approval{Status: StatusApproved, ApprovedBy: "alice"}}, want: ""},
+		{name: "in review", post: Post{Approval: Approval{Status: StatusInReview}}, want: "not approved (status in_review)"},
+		{name: "rejected", post: Post{Approval: Approval{Status: StatusRejected}}, want: "not approved (status rejected)"},
+		{name: "dry run draft", require: true, post: Post{DryRun: true, Approval: Approval{Status: StatusDraft}}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{RequireApproval: tt.require}
+			if got := cfg.ApprovalBlock(tt.post); got != tt.want {
+				t.Errorf("ApprovalBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck_Approval(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+require_approval: true
+posts:
+  - id: launch
+    content: "Launch day"
+    scheduled_at: 2099-01-01T09:00:00Z
+    enabled: true
+    status: approved
+    approved_by: alice
+    approved_at: 2098-12-30T17:00:00Z
+  - id: teaser
+    content: "Coming soon"
+    scheduled_at: 2099-01-01T10:00:00Z
+    enabled: true
+    status: in_review
+  - id: recap
+    content: "Recap"
+    scheduled_at: 2099-01-01T11:00:00Z
+    enabled: true
+  - id: typo
+    content: "Typo"
+    scheduled_at: 2099-01-01T12:00:00Z
+    status: aproved
+queues:
+  - name: tips
+    enabled: true
+    slots:
+      - at: "09:00"
+    status: draft
+    items:
+      - content: "Tip"
+`,
+	})
+
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	launch := cfg.Posts[0]
+	if launch.ApprovedBy != "alice" || !launch.ApprovedAt.Equal(time.Date(2098, 12, 30, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("approval = %+v, want approved by alice at 2098-12-30T17:00:00Z", launch.Approval)
+	}
+
+	var got []string
+	for _, f := range cfg.Check() {
+		got = append(got, string(f.Severity)+": "+f.Message)
+	}
+	want := []string{
+		"warning: queue tips is enabled but not approved (status draft)",
+		"warning: post teaser is enabled but not approved (status in_review)",
+		"warning: post recap is enabled but not approved (no status)",
+		`error: post typo: unknown status "aproved" (want draft, in_review, approved or rejected)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Check() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Represents a period in which no posts are published
+//
+// Exactly one of a date range (from/to), a list of dates, recurring quiet
+// hours or an iCalendar file must be set. Dates are interpreted in the local
+// time zone.
+type Blackout struct {
+	Name       string      `yaml:"name"`                  // Unique name shown in reports
+	From       string      `yaml:"from,omitempty"`        // Date (2006-01-02) or RFC 3339 time
+	To         string      `yaml:"to,omitempty"`          // Inclusive date or exclusive RFC 3339 time
+	Dates      []string    `yaml:"dates,omitempty"`       // Named calendar of whole days
+	QuietHours *QuietHours `yaml:"quiet_hours,omitempty"` // Recurring daily quiet period
+	Calendar   string      `yaml:"calendar,omitempty"`    // .ics file whose events are blackout periods
+
+	events []CalendarEvent // Loaded from the calendar file
+	source Source
+}
+
+// Represents a recurring daily quiet period
+type QuietHours struct {
+	Start    string   `yaml:"start"`              // Wall clock time, e.g. "22:00"
+	End      string   `yaml:"end"`                // May be earlier than start to wrap past midnight
+	Weekdays []string `yaml:"weekdays,omitempty"` // Days the period starts on (default: every day)
+}
+
+// Checks the blackout for errors
+func (b Blackout) Validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("blackout: name is required")
+	}
+
+	kinds := 0
+	if b.From != "" || b.To != "" {
+		kinds++
+	}
+	if len(b.Dates) > 0 {
+		kinds++
+	}
+	if b.QuietHours != nil {
+		kinds++
+	}
+	if b.Calendar != "" {
+		kinds++
+	}
+	if kinds != 1 {
+		return fmt.Errorf("blackout %s: exactly one of from/to, dates, quiet_hours or calendar is required", b.Name)
+	}
+
+	switch {
+	case b.From != "" || b.To != "":
+		start, end, err := b.rangeIn(time.Local)
+		if err != nil {
+			return fmt.Errorf("blackout %s: %w", b.Name, err)
+		}
+		if !end.After(start) {
+			return fmt.Errorf("blackout %s: to must be after from", b.Name)
+		}
+	case len(b.Dates) > 0:
+		for _, date := range b.Dates {
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				return fmt.Errorf("blackout %s: invalid date %q (want YYYY-MM-DD)", b.Name, date)
+			}
+		}
+	case b.QuietHours != nil:
+		if err := b.QuietHours.validate(); err != nil {
+			return fmt.Errorf("blackout %s: %w", b.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Reports whether the time falls within the blackout
+func (b Blackout) Covers(t time.Time) bool {
+	switch {
+	case b.From != "" || b.To != "":
+		start, end, err := b.rangeIn(t.Location())
+		return err == nil && !t.Before(start) && t.Before(end)
+	case len(b.Dates) > 0:
+		day := t.Format("2006-01-02")
+		for _, date := range b.Dates {
+			if date == day {
+				return true
+			}
+		}
+		return false
+	case b.QuietHours != nil:
+		return b.QuietHours.covers(t)
+	case b.Calendar != "":
+		for _, event := range b.events {
+			if !t.Before(event.Start) && t.Before(event.End) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// Returns a human readable description of the blackout
+func (b Blackout) String() string {
+	switch {
+	case b.From != "" || b.To != "":
+		return fmt.Sprintf("blackout %s (%s to %s)", b.Name, b.From, b.To)
+	case len(b.Dates) > 0:
+		return fmt.Sprintf("blackout %s (calendar of %d dates)", b.Name, len(b.Dates))
+	case b.QuietHours != nil:
+		days := "daily"
+		if len(b.QuietHours.Weekdays) > 0 {
+			days = strings.Join(b.QuietHours.Weekdays, ",")
+		}
+		return fmt.Sprintf("blackout %s (quiet hours %s-%s %s)", b.Name, b.QuietHours.Start, b.QuietHours.End, days)
+	case b.Calendar != "":
+		return fmt.Sprintf("blackout %s (calendar %s)", b.Name, b.Calendar)
+	}
+	return fmt.Sprintf("blackout %s", b.Name)
+}
+
+// Returns the blackout range as [start, end) in the given location
+func (b Blackout) rangeIn(loc *time.Location) (time.Time, time.Time, error) {
+	if b.From == "" || b.To == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("both from and to are required")
+	}
+	start, _, err := parseDateOrTime(b.From, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+	end, isDate, err := parseDateOrTime(b.To, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+	// A date as end of the range includes the whole day
+	if isDate {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// Parses a YYYY-MM-DD date in the given location or an RFC 3339 time
+func parseDateOrTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is neither YYYY-MM-DD nor RFC 3339", value)
+	}
+	return t, false, nil
+}
+
+// Checks the quiet hours for errors
+func (q *QuietHours) validate() error {
+	if _, err := parseClock(q.Start); err != nil {
+		return fmt.Errorf("invalid quiet_hours start: %w", err)
+	}
+	if _, err := parseClock(q.End); err != nil {
+		return fmt.Errorf("invalid quiet_hours end: %w", err)
+	}
+	for _, day := range q.Weekdays {
+		if _, ok := parseWeekday(day); !ok {
+			return fmt.Errorf("invalid weekday %q (want mon, tue, ... sun)", day)
+		}
+	}
+	return nil
+}
+
+// Reports whether the time falls within the quiet hours
+func (q *QuietHours) covers(t time.Time) bool {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+
+	switch {
+	case start == end:
+		return q.onWeekday(t.Weekday())
+	case start < end:
+		return minute >= start && minute < end && q.onWeekday(t.Weekday())
+	default:
+		// The period wraps past midnight and belongs to the day it started
+		if minute >= start {
+			return q.onWeekday(t.Weekday())
+		}
+		return minute < end && q.onWeekday(t.AddDate(0, 0, -1).Weekday())
+	}
+}
+
+// Reports whether quiet hours start on the given weekday
+func (q *QuietHours) onWeekday(day time.Weekday) bool {
+	if len(q.Weekdays) == 0 {
+		return true
+	}
+	for _, name := range q.Weekdays {
+		if wd, ok := parseWeekday(name); ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses an HH:MM wall clock time into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Parses a short or long English weekday name
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(name)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Returns the first blackout covering the time, unless the post ignores blackouts
+func (c *Config) BlackoutFor(post Post, t time.Time) *Blackout {
+	if post.IgnoreBlackout {
+		return nil
+	}
+	for i := range c.Blackouts {
+		if c.Blackouts[i].Covers(t) {
+			return &c.Blackouts[i]
+		}
+	}
+	return nil
+}
+
+// Represents an enabled post scheduled within a blackout
+type BlackoutMatch struct {
+	Post     Post
+	Blackout Blackout
+}
+
+// Returns enabled future posts whose scheduled time falls within a blackout
+func (c *Config) BlackedOutPosts(now time.Time) []BlackoutMatch {
+	var matches []BlackoutMatch
+	for _, post := range c.GetEnabledPosts() {
+		if post.Test || !post.ScheduledAt.After(now) {
+			continue
+		}
+		if blackout := c.BlackoutFor(post, post.ScheduledAt.In(now.Location())); blackout != nil {
+			matches = append(matches, BlackoutMatch{Post: post, Blackout: *blackout})
+		}
+	}
+	return matches
+}
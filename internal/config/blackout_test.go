@@ -0,0 +1,150 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlackout_Covers(t *testing.T) {
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		blackout Blackout
+		time     time.Time
+		want     bool
+	}{
+		{
+			name:     "date range includes the whole last day",
+			blackout: Blackout{Name: "holidays", From: "2024-12-29", To: "2025-01-03"},
+			time:     time.Date(2025, 1, 3, 23, 0, 0, 0, time.UTC),
+			want:     true,
+		},
+		{
+			name:     "date range excludes the following day",
+			blackout: Blackout{Name: "holidays", From: "2024-12-29", To: "2025-01-03"},
+			time:     time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+		{
+			name:     "time range end is exclusive",
+			blackout: Blackout{Name: "incident", From: "2024-06-01T09:00:00Z", To: "2024-06-01T12:00:00Z"},
+			time:     at(6, 1, 12, 0),
+			want:     false,
+		},
+		{
+			name:     "time range start is inclusive",
+			blackout: Blackout{Name: "incident", From: "2024-06-01T09:00:00Z", To: "2024-06-01T12:00:00Z"},
+			time:     at(6, 1, 9, 0),
+			want:     true,
+		},
+		{
+			name:     "named calendar date",
+			blackout: Blackout{Name: "jp", Dates: []string{"2024-05-03", "2024-05-06"}},
+			time:     at(5, 6, 10, 0),
+			want:     true,
+		},
+		{
+			name:     "named calendar other date",
+			blackout: Blackout{Name: "jp", Dates: []string{"2024-05-03", "2024-05-06"}},
+			time:     at(5, 7, 10, 0),
+			want:     false,
+		},
+		{
+			name:     "quiet hours within the same day",
+			blackout: Blackout{Name: "lunch", QuietHours: &QuietHours{Start: "12:00", End: "13:00"}},
+			time:     at(6, 3, 12, 30),
+			want:     true,
+		},
+		{
+			name:     "quiet hours wrapping past midnight after start",
+			blackout: Blackout{Name: "night", QuietHours: &QuietHours{Start: "22:00", End: "07:00"}},
+			time:     at(6, 3, 23, 0),
+			want:     true,
+		},
+		{
+			name:     "quiet hours wrapping past midnight before end",
+			blackout: Blackout{Name: "night", QuietHours: &QuietHours{Start: "22:00", End: "07:00"}},
+			time:     at(6, 4, 6, 59),
+			want:     true,
+		},
+		{
+			name:     "quiet hours end is exclusive",
+			blackout: Blackout{Name: "night", QuietHours: &QuietHours{Start: "22:00", End: "07:00"}},
+			time:     at(6, 4, 7, 0),
+			want:     false,
+		},
+		{
+			name: "weekday quiet hours belong to the day they start",
+			blackout: Blackout{Name: "weekend", QuietHours: &QuietHours{
+				Start: "22:00", End: "07:00", Weekdays: []string{"sat"},
+			}},
+			time: at(6, 2, 6, 0), // Sunday morning after Saturday night
+			want: true,
+		},
+		{
+			name: "weekday quiet hours skip other days",
+			blackout: Blackout{Name: "weekend", QuietHours: &QuietHours{
+				Start: "22:00", End: "07:00", Weekdays: []string{"sat"},
+			}},
+			time: at(6, 2, 23, 0), // Sunday night
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.blackout.Covers(tt.time); got != tt.want {
+				t.Errorf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlackout_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		blackout Blackout
+		wantErr  bool
+	}{
+		{name: "valid range", blackout: Blackout{Name: "a", From: "2024-01-01", To: "2024-01-02"}},
+		{name: "valid dates", blackout: Blackout{Name: "a", Dates: []string{"2024-01-01"}}},
+		{name: "valid quiet hours", blackout: Blackout{Name: "a", QuietHours: &QuietHours{Start: "22:00", End: "06:00", Weekdays: []string{"Friday", "sat"}}}},
+		{name: "missing name", blackout: Blackout{Dates: []string{"2024-01-01"}}, wantErr: true},
+		{name: "no period", blackout: Blackout{Name: "a"}, wantErr: true},
+		{name: "two kinds", blackout: Blackout{Name: "a", Dates: []string{"2024-01-01"}, From: "2024-01-01", To: "2024-01-02"}, wantErr: true},
+		{name: "open range", blackout: Blackout{Name: "a", From: "2024-01-01"}, wantErr: true},
+		{name: "reversed range", blackout: Blackout{Name: "a", From: "2024-01-02T00:00:00Z", To: "2024-01-01T00:00:00Z"}, wantErr: true},
+		{name: "bad date", blackout: Blackout{Name: "a", Dates: []string{"01/01/2024"}}, wantErr: true},
+		{name: "bad clock", blackout: Blackout{Name: "a", QuietHours: &QuietHours{Start: "25:00", End: "06:00"}}, wantErr: true},
+		{name: "bad weekday", blackout: Blackout{Name: "a", QuietHours: &QuietHours{Start: "22:00", End: "06:00", Weekdays: []string{"someday"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.blackout.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_BlackoutFor(t *testing.T) {
+	config := Config{
+		Blackouts: []Blackout{{Name: "day", Dates: []string{"2024-06-01"}}},
+	}
+	during := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	if blackout := config.BlackoutFor(Post{}, during); blackout == nil || blackout.Name != "day" {
+		t.Errorf("BlackoutFor() = %v, want blackout 'day'", blackout)
+	}
+	if blackout := config.BlackoutFor(Post{IgnoreBlackout: true}, during); blackout != nil {
+		t.Errorf("BlackoutFor() = %v, want nil for post ignoring blackouts", blackout)
+	}
+	if blackout := config.BlackoutFor(Post{}, during.AddDate(0, 0, 1)); blackout != nil {
+		t.Errorf("BlackoutFor() = %v, want nil outside the blackout", blackout)
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How far ahead recurring calendar events are expanded
+const calendarHorizon = 366 * 24 * time.Hour
+
+// Selects which event fields become the post content
+type CalendarContent string
+
+const (
+	ContentSummary     CalendarContent = "summary"     // Event summary (default)
+	ContentDescription CalendarContent = "description" // Event description
+	ContentBoth        CalendarContent = "both"        // Summary, blank line, description
+)
+
+// Represents posts generated from the events of an iCalendar file
+type CalendarPosts struct {
+	File    string          `yaml:"file"`              // .ics file, relative to the config file
+	Content CalendarContent `yaml:"content,omitempty"` // Event fields used as content
+	At      string          `yaml:"at,omitempty"`      // Posting time for all-day events (default 09:00)
+	Account string          `yaml:"account,omitempty"` // xurl username to post as
+	Enabled bool            `yaml:"enabled"`           // Whether the generated posts are enabled
+
+	Approval `yaml:",inline"`
+
+	source Source
+	path   string // File resolved against the config file that contains the entry
+}
+
+// Returns the content mode, defaulting to the event summary
+func (c CalendarPosts) ContentOrDefault() CalendarContent {
+	if c.Content == "" {
+		return ContentSummary
+	}
+	return c.Content
+}
+
+// Checks the calendar posts settings for errors
+func (c CalendarPosts) Validate() error {
+	if c.File == "" {
+		return fmt.Errorf("calendar_posts: file is required")
+	}
+	switch c.ContentOrDefault() {
+	case ContentSummary, ContentDescription, ContentBoth:
+	default:
+		return fmt.Errorf("calendar_posts %s: unknown content %q (want summary, description or both)", c.File, c.Content)
+	}
+	if c.At != "" {
+		if _, err := parseClock(c.At); err != nil {
+			return fmt.Errorf("calendar_posts %s: invalid at: %w", c.File, err)
+		}
+	}
+	return nil
+}
+
+// Returns the position of the entry in the config files
+func (c CalendarPosts) Source() Source {
+	return c.source
+}
+
+// Converts a calendar event occurrence into a post
+func (c CalendarPosts) post(event CalendarEvent) Post {
+	post := Post{
+		Account:     c.Account,
+		ScheduledAt: event.Start,
+		Enabled:     c.Enabled,
+		Approval:    c.Approval,
+	}
+
+	switch c.ContentOrDefault() {
+	case ContentDescription:
+		post.Content = event.Description
+	case ContentBoth:
+		post.Content = strings.TrimSpace(event.Summary + "\n\n" + event.Description)
+	default:
+		post.Content = event.Summary
+	}
+
+	if event.AllDay {
+		at := 9 * 60
+		if c.At != "" {
+			at, _ = parseClock(c.At)
+		}
+		y, m, d := event.Start.Date()
+		post.ScheduledAt = time.Date(y, m, d, at/60, at%60, 0, 0, event.Start.Location())
+	}
+
+	// Occurrences of a recurring event share the UID, so the date is appended
+	if event.UID != "" {
+		post.ID = strings.Join(strings.Fields(event.UID), "-")
+		if event.Recurring {
+			layout := "20060102T1504"
+			if event.AllDay {
+				layout = "20060102"
+			}
+			post.ID += "@" + event.Start.Format(layout)
+		}
+	}
+
+	return post
+}
+
+// Reads the calendars referenced by blackouts and calendar posts
+//
+// Blackouts get the events overlapping the next year; calendar posts
+// become regular posts for occurrences from the start of today on.
+func (c *Config) loadCalendars(now time.Time) error {
+	until := now.Add(calendarHorizon)
+
+	for i := range c.Blackouts {
+		blackout := &c.Blackouts[i]
+		if blackout.Calendar == "" {
+			continue
+		}
+		events, err := ReadCalendar(c.ResolvePath(blackout.Calendar), now.Add(-24*time.Hour), until)
+		if err != nil {
+			return fmt.Errorf("blackout %s: %w", blackout.Name, err)
+		}
+		blackout.events = events
+	}
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	for i := range c.CalendarPosts {
+		source := &c.CalendarPosts[i]
+		if err := source.Validate(); err != nil {
+			return err
+		}
+		path := c.ResolvePath(source.File)
+		source.path = path
+		events, err := ReadCalendar(path, today, until)
+		if err != nil {
+			return fmt.Errorf("calendar_posts: %w", err)
+		}
+		for _, event := range events {
+			post := source.post(event)
+			post.Source = Source{File: path}
+			post.Calendar = source.File
+			c.Posts = append(c.Posts, post)
+		}
+	}
+
+	return nil
+}
@@ -2,42 +2,215 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/zinrai/x-scheduler/internal/content"
 )
 
-// Load reads and parses the YAML configuration file
-func Load(filename string) (*Config, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+// Checks the configuration for errors
+//
+// All errors are returned together as ValidationErrors, positioned at the
+// offending entry when the config was loaded from files. Lint errors are
+// left out, as they hold back only the posts they are about.
+func (c *Config) Validate() error {
+	return c.Check().WithoutLint().Err()
+}
+
+// Returns every error, warning and informational finding about the config
+func (c *Config) Check() Findings {
+	var findings Findings
+	if len(c.Posts) == 0 && len(c.CalendarPosts) == 0 && len(c.Queues) == 0 {
+		findings.add(SeverityError, Source{}, "no posts configured")
+		return findings
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	if c.Missed != nil {
+		if err := c.Missed.Validate(); err != nil {
+			findings.add(SeverityError, c.sourceOf("missed"), "%v", err)
+		}
+	}
+	if c.Jitter < 0 {
+		findings.add(SeverityError, c.sourceOf("jitter"), "jitter must not be negative")
+	}
+	if c.Spacing != nil {
+		if err := c.Spacing.Validate(); err != nil {
+			findings.add(SeverityError, c.sourceOf("spacing"), "%v", err)
+		}
+	}
+	if c.Limits != nil {
+		if err := c.Limits.Validate(); err != nil {
+			findings.add(SeverityError, c.sourceOf("limits"), "%v", err)
+		}
+	}
+	if c.UTM != nil {
+		if err := c.UTM.Validate(); err != nil {
+			findings.add(SeverityError, c.sourceOf("utm"), "%v", err)
+		}
+	}
+	if c.Signatures != nil {
+		if err := c.Signatures.Validate(); err != nil {
+			findings.add(SeverityError, c.sourceOf("signatures"), "%v", err)
+		}
+	}
+	if c.Pause != nil {
+		if err := c.Pause.Validate(); err != nil {
+			findings.add(SeverityError, c.sourceOf("pause"), "%v", err)
+		}
+	}
+	// Content is linted only against valid rules
+	lint := c.Lint
+	if lint != nil {
+		if err := lint.Validate(); err != nil {
+			findings.add(SeverityError, c.sourceOf("lint"), "%v", err)
+			lint = nil
+		}
+	}
+	blackoutNames := make(map[string]bool)
+	for _, blackout := range c.Blackouts {
+		if err := blackout.Validate(); err != nil {
+			findings.add(SeverityError, blackout.source, "%v", err)
+			continue
+		}
+		if blackoutNames[blackout.Name] {
+			findings.add(SeverityError, blackout.source, "blackout %s: duplicate name", blackout.Name)
+		}
+		blackoutNames[blackout.Name] = true
+	}
+	for _, source := range c.CalendarPosts {
+		if err := source.Validate(); err != nil {
+			findings.add(SeverityError, source.source, "%v", err)
+		} else if err := source.Approval.Validate(); err != nil {
+			findings.add(SeverityError, source.source, "calendar %s: %v", source.File, err)
+		}
 	}
 
-	return &config, nil
-}
+	now := time.Now()
+	queueNames := make(map[string]bool)
+	for _, queue := range c.Queues {
+		if err := queue.Validate(); err != nil {
+			findings.add(SeverityError, queue.source, "%v", err)
+			continue
+		}
+		if queueNames[queue.Name] {
+			findings.add(SeverityError, queue.source, "queue %s: duplicate name", queue.Name)
+		}
+		queueNames[queue.Name] = true
+		if err := queue.Approval.Validate(); err != nil {
+			findings.add(SeverityError, queue.source, "queue %s: %v", queue.Name, err)
+		} else if reason := c.ApprovalBlock(queue.Post(QueueItem{}, now)); reason != "" && queue.Enabled {
+			findings.add(SeverityWarning, queue.source, "queue %s is enabled but %s", queue.Name, reason)
+		}
 
-// Checks the configuration for errors
-func (c *Config) Validate() error {
-	if len(c.Posts) == 0 {
-		return fmt.Errorf("no posts configured")
+		for i, item := range queue.Items {
+			rendered, err := c.RenderContent(queue.Post(item, now), now)
+			if err != nil {
+				findings.add(SeverityError, queue.source, "queue %s: item %d: %v", queue.Name, i+1, err)
+				continue
+			}
+			if length := content.WeightedLength(rendered); length > content.MaxLength && !item.AutoThread {
+				findings.add(SeverityError, queue.source, "queue %s: item %d: content is %d characters long (maximum %d)",
+					queue.Name, i+1, length, content.MaxLength)
+			}
+			for _, problem := range lint.Problems(rendered) {
+				findings.addLint(problem, queue.source, fmt.Sprintf("queue %s: item %d", queue.Name, i+1))
+			}
+		}
 	}
 
-	now := time.Now()
 	pastPostCount := 0
+	seen := make(map[string]int)
+	errorsBefore := findings.Count(SeverityError)
 
 	for i, post := range c.Posts {
-		if post.Content == "" {
-			return fmt.Errorf("post %d: content is required", i)
+		label := postLabel(i, post)
+
+		if strings.ContainsAny(post.ID, " \t\r\n") {
+			findings.add(SeverityError, post.sourceOf("id"), "%s: id must not contain whitespace", label)
+		}
+		if len(post.Localized) > 0 {
+			if err := c.validateLanguages(post); err != nil {
+				findings.add(SeverityError, post.sourceOf("content"), "%s: %v", label, err)
+			}
+		} else if err := post.validateVariants(); err != nil {
+			findings.add(SeverityError, post.sourceOf("variants"), "%s: %v", label, err)
+		} else if post.Content == "" && len(post.Variants) == 0 {
+			findings.add(SeverityError, post.sourceOf("content"), "%s: content is required", label)
+		}
+		if post.Numbered && !post.AutoThread {
+			findings.add(SeverityError, post.sourceOf("numbered"), "%s: numbered requires auto_thread", label)
 		}
 		if post.ScheduledAt.IsZero() {
-			return fmt.Errorf("post %d: scheduled_at is required", i)
+			findings.add(SeverityError, post.sourceOf("scheduled_at"), "%s: scheduled_at is required", label)
+			continue
+		}
+
+		// Templates are rendered ahead of time so that errors and overlong
+		// results surface before the post is due. Lint results are reported
+		// by identifier, which stays the same when posts move.
+		lintTexts := post.texts("post " + post.Identifier())
+		for j, text := range post.texts(label) {
+			if text.post.Content == "" {
+				continue
+			}
+			rendered, err := c.RenderContent(text.post, post.ScheduledAt)
+			if err != nil {
+				findings.add(SeverityError, post.sourceOf(text.field), "%s: %v", text.label, err)
+				continue
+			}
+			switch length := content.WeightedLength(rendered); {
+			case length > content.MaxLength && !post.AutoThread:
+				findings.add(SeverityError, post.sourceOf(text.field), "%s: content is %d characters long (maximum %d)",
+					text.label, length, content.MaxLength)
+			case length > content.MaxLength:
+				findings.add(SeverityInfo, post.sourceOf(text.field), "%s: content is %d characters long and will be posted as a thread of %d",
+					text.label, length, len(content.Split(rendered, post.Numbered)))
+			case post.ContentFile != "":
+				findings.add(SeverityInfo, post.sourceOf("content_file"), "%s: %s is %d of %d characters",
+					text.label, post.ContentFile, length, content.MaxLength)
+			}
+			for _, problem := range lint.Problems(rendered) {
+				findings.addLint(problem, post.sourceOf(text.field), lintTexts[j].label)
+			}
+		}
+
+		// Upcoming posts held back by the approval workflow will be skipped
+		if err := post.Approval.Validate(); err != nil {
+			findings.add(SeverityError, post.sourceOf("status"), "%s: %v", label, err)
+		} else if reason := c.ApprovalBlock(post); reason != "" && post.Enabled && (post.Test || post.ScheduledAt.After(now)) {
+			findings.add(SeverityWarning, post.sourceOf("status"), "%s is enabled but %s", label, reason)
+		}
+
+		if post.Missed != nil {
+			if err := post.Missed.Validate(); err != nil {
+				findings.add(SeverityError, post.sourceOf("missed"), "%s: %v", label, err)
+			}
+		}
+
+		if post.UTM != nil {
+			if err := post.UTM.Validate(); err != nil {
+				findings.add(SeverityError, post.sourceOf("utm"), "%s: %v", label, err)
+			}
+		}
+
+		if post.Jitter != nil && *post.Jitter < 0 {
+			findings.add(SeverityError, post.sourceOf("jitter"), "%s: jitter must not be negative", label)
+		}
+
+		// Identifiers key the run state, so they must be unique
+		id := post.Identifier()
+		if first, ok := seen[id]; ok {
+			switch {
+			case c.Posts[first].Source.File != "":
+				findings.add(SeverityError, post.sourceOf("id"), "%s: duplicate id (also used by post %d at %s)",
+					label, first, c.Posts[first].Source)
+			case post.ID != "":
+				findings.add(SeverityError, post.sourceOf("id"), "%s: duplicate id (also used by post %d)", label, first)
+			default:
+				findings.add(SeverityError, post.Source, "%s: duplicate of post %d (same content and scheduled_at)", label, first)
+			}
+		} else {
+			seen[id] = i
 		}
 
 		// Count past posts but don't fail validation
@@ -45,17 +218,87 @@ func (c *Config) Validate() error {
 			pastPostCount++
 			if post.Enabled {
 				// Only warn about enabled posts in the past
-				fmt.Printf("Warning: post %d is scheduled in the past but enabled: %s\n",
-					i, post.ScheduledAt.Format("2006-01-02 15:04"))
+				findings.add(SeverityWarning, post.sourceOf("scheduled_at"), "%s is scheduled in the past but enabled: %s",
+					label, post.ScheduledAt.Format("2006-01-02 15:04"))
 			}
 		}
 	}
 
 	if pastPostCount > 0 {
-		fmt.Printf("Info: %d posts are scheduled in the past (consider disabling them)\n", pastPostCount)
+		findings.add(SeverityInfo, Source{}, "%d posts are scheduled in the past (consider disabling them)", pastPostCount)
+	}
+
+	// Schedule-wide checks need every post to be valid
+	if findings.Count(SeverityError) > errorsBefore {
+		return findings
 	}
 
-	return nil
+	// Spacing conflicts fail validation unless the executor may shift posts
+	for _, conflict := range c.SpacingConflicts(now) {
+		if c.Spacing.PolicyOrDefault() == SpacingFail {
+			findings.add(SeverityError, conflict.Second.sourceOf("scheduled_at"),
+				"%s: spacing conflict with post %s on account %s (%v apart, minimum gap %v)",
+				postLabel(conflict.SecondIndex, conflict.Second), conflict.First.Identifier(),
+				AccountName(conflict.Account), conflict.Gap, conflict.MinGap)
+			continue
+		}
+		findings.add(SeverityInfo, conflict.Second.sourceOf("scheduled_at"), "spacing conflict will be shifted: %s", conflict)
+	}
+
+	// Hourly overflow can be deferred, but there is no later slot for daily overflow
+	for _, violation := range c.LimitViolations(now) {
+		switch {
+		case c.Limits.OverflowOrDefault() == OverflowReject:
+			findings.add(SeverityError, violation.Source(), "limit exceeded: %s", violation)
+		case violation.Period == "day":
+			findings.add(SeverityWarning, violation.Source(), "limit exceeded, excess posts will be rejected: %s", violation)
+		default:
+			findings.add(SeverityInfo, violation.Source(), "limit exceeded, excess posts will be deferred: %s", violation)
+		}
+	}
+
+	return findings
+}
+
+// Returns a label identifying the post in messages
+func postLabel(index int, post Post) string {
+	if post.ID != "" {
+		return fmt.Sprintf("post %s", post.ID)
+	}
+	return fmt.Sprintf("post %d", index)
+}
+
+// Represents one of the texts a post may be published with
+type postText struct {
+	post  Post   // The post with its content set to the text
+	field string // Field the text is defined in
+	label string // Label identifying the text in messages
+}
+
+// Returns every text of the post: each variant, each language, or its content
+func (p Post) texts(label string) []postText {
+	var texts []postText
+	switch {
+	case len(p.Localized) > 0:
+		for _, lang := range p.Languages() {
+			texts = append(texts, postText{p.WithLanguage(lang), "content", fmt.Sprintf("%s (%s)", label, lang)})
+		}
+	case len(p.Variants) > 0:
+		for i := range p.Variants {
+			texts = append(texts, postText{p.WithVariant(i), "variants", fmt.Sprintf("%s variant %d", label, i+1)})
+		}
+	default:
+		texts = append(texts, postText{p, "content", label})
+	}
+	return texts
+}
+
+// Returns the jitter that applies to the post
+func (c *Config) JitterFor(post Post) time.Duration {
+	if post.Jitter != nil {
+		return *post.Jitter
+	}
+	return c.Jitter
 }
 
 // Returns only enabled posts
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -48,6 +49,103 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "post 0: scheduled_at is required",
 		},
+		{
+			name: "post error should be labeled with its id",
+			config: Config{
+				Posts: []Post{
+					{
+						ID:          "welcome",
+						Content:     "",
+						ScheduledAt: time.Now().Add(time.Hour),
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "post welcome: content is required",
+		},
+		{
+			name: "duplicate ids should return error",
+			config: Config{
+				Posts: []Post{
+					{ID: "dup", Content: "First", ScheduledAt: time.Now().Add(time.Hour)},
+					{ID: "dup", Content: "Second", ScheduledAt: time.Now().Add(2 * time.Hour)},
+				},
+			},
+			wantErr: true,
+			errMsg:  "post dup: duplicate id (also used by post 0)",
+		},
+		{
+			name: "identical posts without ids should return error",
+			config: Config{
+				Posts: []Post{
+					{Content: "Same", ScheduledAt: time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)},
+					{Content: "Same", ScheduledAt: time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)},
+				},
+			},
+			wantErr: true,
+			errMsg:  "post 1: duplicate of post 0 (same content and scheduled_at)",
+		},
+		{
+			name: "id with whitespace should return error",
+			config: Config{
+				Posts: []Post{
+					{ID: "bad id", Content: "Test content", ScheduledAt: time.Now().Add(time.Hour)},
+				},
+			},
+			wantErr: true,
+			errMsg:  "post bad id: id must not contain whitespace",
+		},
+		{
+			name: "template error should return error",
+			config: Config{
+				Posts: []Post{
+					{ID: "countdown", Content: "{{.Vars.event}} soon", ScheduledAt: time.Now().Add(time.Hour), Template: true},
+				},
+			},
+			wantErr: true,
+			errMsg:  `post countdown: failed to render template: template: content:1:7: executing "content" at <.Vars.event>: map has no entry for key "event"`,
+		},
+		{
+			name: "content over the length limit should return error",
+			config: Config{
+				Vars: map[string]string{"word": "ten chars "},
+				Posts: []Post{
+					{ID: "long", Content: `{{range 29}}{{$.Vars.word}}{{end}}`,
+						ScheduledAt: time.Now().Add(time.Hour), Template: true},
+				},
+			},
+			wantErr: true,
+			errMsg:  "post long: content is 290 characters long (maximum 280)",
+		},
+		{
+			name: "long content with auto_thread should pass validation",
+			config: Config{
+				Posts: []Post{
+					{Content: strings.Repeat("A sentence. ", 40), ScheduledAt: time.Now().Add(time.Hour), AutoThread: true, Numbered: true},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "numbered without auto_thread should return error",
+			config: Config{
+				Posts: []Post{
+					{ID: "short", Content: "Short", ScheduledAt: time.Now().Add(time.Hour), Numbered: true},
+				},
+			},
+			wantErr: true,
+			errMsg:  "post short: numbered requires auto_thread",
+		},
+		{
+			name: "template content should pass validation",
+			config: Config{
+				Vars: map[string]string{"event": "GopherCon"},
+				Posts: []Post{
+					{Content: `{{.Vars.event}} in {{daysUntil "2099-01-01"}} days`, ScheduledAt: time.Now().Add(time.Hour), Template: true},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "valid config should pass validation",
 			config: Config{
@@ -131,3 +229,64 @@ func TestConfig_GetFuturePosts(t *testing.T) {
 		t.Errorf("GetFuturePosts()[0].Content = %v, want 'Future post'", future[0].Content)
 	}
 }
+
+func TestPost_Identifier(t *testing.T) {
+	scheduledAt := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	post := Post{Content: "Hello", ScheduledAt: scheduledAt}
+
+	if got := (Post{ID: "welcome", Content: "Hello"}).Identifier(); got != "welcome" {
+		t.Errorf("Identifier() = %v, want 'welcome'", got)
+	}
+
+	// Fallback must be deterministic and independent of the time zone
+	sameInstant := Post{Content: "Hello", ScheduledAt: scheduledAt.In(time.FixedZone("JST", 9*60*60))}
+	if post.Identifier() != sameInstant.Identifier() {
+		t.Errorf("Identifier() differs for the same instant in different zones")
+	}
+	if len(post.Identifier()) != 16 {
+		t.Errorf("Identifier() = %v, want 16 hex characters", post.Identifier())
+	}
+
+	otherContent := Post{Content: "Hello!", ScheduledAt: scheduledAt}
+	if post.Identifier() == otherContent.Identifier() {
+		t.Errorf("Identifier() should differ when content differs")
+	}
+
+	otherTime := Post{Content: "Hello", ScheduledAt: scheduledAt.Add(time.Minute)}
+	if post.Identifier() == otherTime.Identifier() {
+		t.Errorf("Identifier() should differ when scheduled time differs")
+	}
+}
+
+func TestConfig_Check(t *testing.T) {
+	now := time.Now()
+	cfg := Config{
+		Spacing: &Spacing{MinGap: 10 * time.Minute, Policy: SpacingShift},
+		Posts: []Post{
+			{ID: "old", Content: "Old", ScheduledAt: now.Add(-48 * time.Hour), Enabled: true},
+			{ID: "a", Content: "A", ScheduledAt: now.Add(48 * time.Hour), Enabled: true},
+			{ID: "b", Content: "B", ScheduledAt: now.Add(48*time.Hour + time.Minute), Enabled: true},
+		},
+	}
+
+	findings := cfg.Check()
+	if err := findings.Err(); err != nil {
+		t.Fatalf("Check() unexpected error = %v", err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, string(f.Severity)+": "+strings.SplitN(f.Message, ":", 2)[0])
+	}
+	want := []string{
+		"warning: post old is scheduled in the past but enabled",
+		"info: 1 posts are scheduled in the past (consider disabling them)",
+		"info: spacing conflict will be shifted",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Check() findings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := findings.Count(SeverityWarning); n != 1 {
+		t.Errorf("Count(warning) = %d, want 1", n)
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/content"
+)
+
+// Returns the content of the post as published at the given time
+//
+// Template posts are rendered with the config vars, then links are tagged
+// with the UTM parameters that apply to the post.
+func (c *Config) RenderContent(post Post, at time.Time) (string, error) {
+	text := post.Content
+	if post.Template {
+		rendered, err := content.Render(post.Content, content.Data{
+			ID:          post.Identifier(),
+			Account:     post.Account,
+			Lang:        post.Lang,
+			ScheduledAt: post.ScheduledAt,
+			Time:        at,
+			Vars:        c.Vars,
+		})
+		if err != nil {
+			return "", err
+		}
+		text = rendered
+	}
+	return c.UTMFor(post).Apply(text), nil
+}
+
+// Reads content files and converts Markdown content to plain text
+func (c *Config) loadContent() ValidationErrors {
+	var errs ValidationErrors
+	for i := range c.Posts {
+		post := &c.Posts[i]
+		label := postLabel(i, *post)
+		if err := c.readContent(&post.Content, post.ContentFile, post.Markdown); err != nil {
+			errs.add(post.sourceOf("content_file"), "%s: %v", label, err)
+			continue
+		}
+		if post.Markdown {
+			for j := range post.Variants {
+				post.Variants[j].Content = content.PlainText(post.Variants[j].Content)
+			}
+		}
+	}
+	for _, queue := range c.Queues {
+		for i := range queue.Items {
+			item := &queue.Items[i]
+			if err := c.readContent(&item.Content, item.ContentFile, item.Markdown); err != nil {
+				errs.add(queue.source, "queue %s: item %d: %v", queue.Name, i+1, err)
+			}
+		}
+	}
+	return errs
+}
+
+// Reads text from file, if set, and converts Markdown to plain text
+func (c *Config) readContent(text *string, file string, markdown bool) error {
+	if file != "" {
+		if *text != "" {
+			return fmt.Errorf("content and content_file are mutually exclusive")
+		}
+		data, err := os.ReadFile(c.ResolvePath(file))
+		if err != nil {
+			return fmt.Errorf("failed to read content_file: %w", err)
+		}
+		*text = strings.TrimSpace(string(data))
+	}
+	if markdown {
+		*text = content.PlainText(*text)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Represents a key and value to set in a config file
+type Field struct {
+	Key   string
+	Value interface{} // Encoded like any other YAML value; nil removes the key
+}
+
+// Represents a replacement of lines [start, end] of a file, 1-based
+type lineEdit struct {
+	start, end int // end is start-1 for an insertion before start
+	lines      []string
+}
+
+// Sets fields of the mapping that starts at source, rewriting its file
+//
+// Only the lines of the changed entries are rewritten; the rest of the file
+// stays byte for byte as it was. Existing keys keep their place and line
+// comments, and missing keys are appended to the mapping.
+func SetFields(source Source, fields []Field) error {
+	data, err := os.ReadFile(source.File)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	info, err := os.Stat(source.File)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", source.File, err)
+	}
+	mapping := findMapping(&doc, source.Line, source.Column)
+	if mapping == nil || len(mapping.Content) == 0 {
+		return fmt.Errorf("%s: no mapping found", source)
+	}
+	if mapping.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("%s: cannot edit a flow style mapping", source)
+	}
+
+	text := string(data)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	lines := strings.SplitAfter(text, "\n")
+	indent := strings.Repeat(" ", mapping.Content[0].Column-1)
+	mappingEnd := blockEnd(lines, mapping.Line, len(lines), len(indent))
+
+	var edits []lineEdit
+	var appended []string
+	for _, field := range fields {
+		i := keyIndex(mapping, field.Key)
+		if i < 0 && field.Value == nil {
+			continue
+		}
+
+		var entry []string
+		if field.Value != nil {
+			var value yaml.Node
+			if err := value.Encode(field.Value); err != nil {
+				return fmt.Errorf("failed to encode %s: %w", field.Key, err)
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field.Key}
+			if i >= 0 {
+				// Comments belong to the entry, not to the old value
+				key.LineComment = mapping.Content[i].LineComment
+				value.LineComment = mapping.Content[i+1].LineComment
+			}
+			if entry, err = encodeEntry(key, &value, detectIndent(data)); err != nil {
+				return fmt.Errorf("failed to encode %s: %w", field.Key, err)
+			}
+		}
+
+		if i < 0 {
+			for _, line := range entry {
+				appended = append(appended, indent+line)
+			}
+			continue
+		}
+
+		// The entry runs up to the next key of the mapping
+		key := mapping.Content[i]
+		end := mappingEnd
+		if i+2 < len(mapping.Content) {
+			end = blockEnd(lines, key.Line, mapping.Content[i+2].Line-1, len(indent))
+		}
+		// The first key of a sequence item shares its line with the dash
+		first := lines[key.Line-1]
+		prefix := first[:key.Column-1]
+		for j, line := range entry {
+			if j == 0 {
+				entry[j] = prefix + line
+			} else {
+				entry[j] = indent + line
+			}
+		}
+		edits = append(edits, lineEdit{start: key.Line, end: end, lines: entry})
+	}
+	if len(appended) > 0 {
+		edits = append(edits, lineEdit{start: mappingEnd + 1, end: mappingEnd, lines: appended})
+	}
+
+	// Applied from the bottom up, so that earlier line numbers stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, edit := range edits {
+		rest := append(append([]string{}, edit.lines...), lines[edit.end:]...)
+		lines = append(lines[:edit.start-1], rest...)
+	}
+
+	out := []byte(strings.Join(lines, ""))
+	var check yaml.Node
+	if err := yaml.Unmarshal(out, &check); err != nil {
+		return fmt.Errorf("%s: edit would produce invalid YAML: %w", source, err)
+	}
+	if err := os.WriteFile(source.File, out, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Returns the lines of a single key/value entry, each ending in a newline
+func encodeEntry(key, value *yaml.Node, indent int) ([]string, error) {
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(indent)
+	entry := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{key, value}}
+	if err := encoder.Encode(entry); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	lines := strings.SplitAfter(out.String(), "\n")
+	return lines[:len(lines)-1], nil
+}
+
+// Returns the last line of the block that starts at line start, looking no
+// further than line limit
+//
+// The block ends before the first content line indented less than indent,
+// and blank and comment lines at its end belong to what follows.
+func blockEnd(lines []string, start, limit, indent int) int {
+	end := start
+	for n := start + 1; n <= limit && n <= len(lines); n++ {
+		line := strings.TrimRight(lines[n-1], "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if len(line)-len(trimmed) < indent {
+			break
+		}
+		end = n
+	}
+	return end
+}
+
+// Returns the index of the key in the mapping's content, or -1
+func keyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the mapping node at the given position
+func findMapping(node *yaml.Node, line, column int) *yaml.Node {
+	if node.Kind == yaml.MappingNode && node.Line == line && node.Column == column {
+		return node
+	}
+	for _, child := range node.Content {
+		if found := findMapping(child, line, column); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Returns the indentation of the first indented line, defaulting to two spaces
+func detectIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return indent
+		}
+	}
+	return 2
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetFields(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+# Launch week
+posts:
+    - id: teaser
+      content: "Coming soon"
+      scheduled_at: 2099-01-01T10:00:00Z
+      status: in_review # waiting on legal
+    - id: launch
+      content: "Launch day"
+      scheduled_at: 2099-01-02T09:00:00Z
+`,
+	})
+	path := filepath.Join(dir, "config.yaml")
+	at := time.Date(2098, 12, 30, 17, 0, 0, 0, time.UTC)
+
+	for _, id := range []string{"teaser", "launch"} {
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		var source Source
+		for _, post := range cfg.Posts {
+			if post.ID == id {
+				source = post.Source
+			}
+		}
+		err = SetFields(source, []Field{
+			{Key: "status", Value: StatusApproved},
+			{Key: "approved_by", Value: "alice"},
+			{Key: "approved_at", Value: at},
+		})
+		if err != nil {
+			t.Fatalf("SetFields(%s) error = %v", id, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Launch week", "status: approved # waiting on legal", "\n    - id: launch\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("rewritten file is missing %q:\n%s", want, data)
+		}
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() after SetFields error = %v", err)
+	}
+	for _, post := range cfg.Posts {
+		if post.Status != StatusApproved || post.ApprovedBy != "alice" || !post.ApprovedAt.Equal(at) {
+			t.Errorf("post %s approval = %+v, want approved by alice at %v", post.ID, post.Approval, at)
+		}
+	}
+}
+
+func TestSetFields_FlowMapping(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+posts:
+  - {id: teaser, content: "Coming soon", scheduled_at: 2099-01-01T10:00:00Z}
+`,
+	})
+	path := filepath.Join(dir, "config.yaml")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	err = SetFields(cfg.Posts[0].Source, []Field{{Key: "status", Value: StatusApproved}})
+	if err == nil || !strings.Contains(err.Error(), "cannot edit a flow style mapping") {
+		t.Errorf("SetFields() error = %v, want flow style error", err)
+	}
+}
+
+func TestSetFields_RemovesNil(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+posts:
+  - id: teaser
+    content: "Coming soon"
+    scheduled_at: 2099-01-01T10:00:00Z
+    signature: c2ln
+`,
+	})
+	path := filepath.Join(dir, "config.yaml")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	err = SetFields(cfg.Posts[0].Source, []Field{{Key: "signature", Value: nil}, {Key: "approved_by", Value: nil}})
+	if err != nil {
+		t.Fatalf("SetFields() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "signature") || strings.Contains(string(data), "approved_by") {
+		t.Errorf("rewritten file still has removed keys:\n%s", data)
+	}
+}
+
+func TestSetFields_KeepsRestOfFile(t *testing.T) {
+	before := `# Launch week
+posts:
+  - id: teaser
+    content: >
+      Follow up text
+      folded
+    scheduled_at: 2099-01-01T10:00:00Z
+
+  # Needs sign-off
+  - status: in_review   # waiting on legal
+    id: launch
+    content: "Launch day"
+    signature: c2ln
+    scheduled_at: 2099-01-02T09:00:00Z
+    # trailing comment
+
+  - id: recap
+    content: |
+      Thanks for
+      joining!
+    scheduled_at: 2099-01-03T09:00:00Z
+vars:
+  event:   GopherCon
+`
+	want := `# Launch week
+posts:
+  - id: teaser
+    content: >
+      Follow up text
+      folded
+    scheduled_at: 2099-01-01T10:00:00Z
+
+  # Needs sign-off
+  - status: approved # waiting on legal
+    id: launch
+    content: "Launch day"
+    scheduled_at: 2099-01-02T09:00:00Z
+    approved_by: alice
+    # trailing comment
+
+  - id: recap
+    content: |
+      Thanks for
+      joining!
+    scheduled_at: 2099-01-03T09:00:00Z
+vars:
+  event:   GopherCon
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(before), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	err = SetFields(cfg.Posts[1].Source, []Field{
+		{Key: "status", Value: StatusApproved},
+		{Key: "approved_by", Value: "alice"},
+		{Key: "signature", Value: nil},
+	})
+	if err != nil {
+		t.Fatalf("SetFields() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("SetFields() wrote\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Classifies validation findings
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The config cannot be used
+	SeverityWarning Severity = "warning" // Likely a mistake, e.g. posts that will be rejected
+	SeverityInfo    Severity = "info"    // Worth knowing, e.g. posts that will be shifted
+)
+
+// Represents a problem found in the config, at a position when loaded from files
+type Finding struct {
+	Severity Severity
+	Source   Source // Zero for configs not loaded from a file
+	Message  string
+	Lint     bool // Raised by a lint rule about a post's text rather than the config itself
+}
+
+// Returns "file:line:col: message", or just the message without a position
+func (f Finding) Error() string {
+	if f.Source.File == "" {
+		return f.Message
+	}
+	return fmt.Sprintf("%s: %s", f.Source, f.Message)
+}
+
+// Represents all findings of a validation run
+type Findings []Finding
+
+// Records a finding at the given position
+func (fs *Findings) add(severity Severity, source Source, format string, args ...interface{}) {
+	*fs = append(*fs, Finding{Severity: severity, Source: source, Message: fmt.Sprintf(format, args...)})
+}
+
+// Records a finding raised by a lint rule
+func (fs *Findings) addLint(problem LintProblem, source Source, label string) {
+	*fs = append(*fs, Finding{Severity: problem.Severity, Source: source, Message: fmt.Sprintf("%s: %s", label, problem), Lint: true})
+}
+
+// Returns the findings that were not raised by lint rules
+//
+// Lint errors hold back only the posts they are about, so they do not make
+// the config unusable.
+func (fs Findings) WithoutLint() Findings {
+	var kept Findings
+	for _, f := range fs {
+		if !f.Lint {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// Returns the number of findings with the given severity
+func (fs Findings) Count(severity Severity) int {
+	count := 0
+	for _, f := range fs {
+		if f.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Returns the error findings as an error, or nil if there are none
+func (fs Findings) Err() error {
+	var errs ValidationErrors
+	for _, f := range fs {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+	return errs.err()
+}
+
+// Represents the error findings that make a config unusable
+type ValidationErrors []Finding
+
+// Returns the single message, or a count followed by one message per line
+func (errs ValidationErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+	lines := []string{fmt.Sprintf("%d errors:", len(errs))}
+	for _, err := range errs {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Records an error at the given position
+func (errs *ValidationErrors) add(source Source, format string, args ...interface{}) {
+	*errs = append(*errs, Finding{Severity: SeverityError, Source: source, Message: fmt.Sprintf(format, args...)})
+}
+
+// Returns the collected errors as an error, or nil if there are none
+func (errs ValidationErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Matches the "line N: message" form used by yaml errors
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// Converts a yaml syntax or type error into positioned problems
+func yamlErrors(filename string, err error) ValidationErrors {
+	messages := []string{err.Error()}
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	}
+
+	var errs ValidationErrors
+	for _, message := range messages {
+		source := Source{File: filename}
+		if m := yamlLinePattern.FindStringSubmatch(message); m != nil {
+			source.Line, _ = strconv.Atoi(m[1])
+			message = m[2]
+		} else {
+			message = strings.TrimPrefix(message, "yaml: ")
+		}
+		errs.add(source, "%s", message)
+	}
+	return errs
+}
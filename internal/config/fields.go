@@ -0,0 +1,138 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+	postType        = reflect.TypeOf(Post{})
+)
+
+// Reports mapping keys that don't match a field of the target type
+//
+// This is what yaml.Decoder.KnownFields does, except that every unknown
+// field is reported with its position instead of stopping at the first.
+func checkKnownFields(node *yaml.Node, t reflect.Type, filename string, errs *ValidationErrors) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types that decode themselves check their own fields; posts only lift
+	// out localized content and are checked like any other struct
+	if t == timeType || (t != postType && reflect.PointerTo(t).Implements(unmarshalerType)) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				source := Source{File: filename, Line: key.Line, Column: key.Column}
+				if suggestion := closestField(key.Value, fields); suggestion != "" {
+					errs.add(source, "unknown field %q (did you mean %q?)", key.Value, suggestion)
+				} else {
+					errs.add(source, "unknown field %q", key.Value)
+				}
+				continue
+			}
+			checkKnownFields(value, field, filename, errs)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range node.Content {
+			checkKnownFields(item, t.Elem(), filename, errs)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			checkKnownFields(node.Content[i], t.Elem(), filename, errs)
+		}
+	}
+}
+
+// Returns the YAML names of the struct's fields and their types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		// Inlined structs contribute their fields to the parent mapping
+		if field.Anonymous && len(tag) > 1 && tag[1] == "inline" {
+			for name, t := range yamlFields(field.Type) {
+				fields[name] = t
+			}
+			continue
+		}
+		name := tag[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// Returns the known field closest to name, if it is a likely typo
+func closestField(name string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for candidate := range fields {
+		names = append(names, candidate)
+	}
+	sort.Strings(names)
+
+	best := ""
+	bestDistance := 3
+	for _, candidate := range names {
+		if distance := editDistance(name, candidate); distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// Returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,748 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maximum number of occurrences expanded from a single recurring event
+const maxOccurrences = 5000
+
+// Represents an occurrence of an iCalendar event
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time // Exclusive; equal to Start for instantaneous events
+	AllDay      bool
+	Recurring   bool // Occurrence of a recurring event
+}
+
+// Represents a property line such as DTSTART;TZID=Asia/Tokyo:20240101T090000
+type icalProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+	Line   int
+}
+
+// Represents a BEGIN/END block and its properties
+type icalComponent struct {
+	Name       string
+	Properties []icalProperty
+	Children   []*icalComponent
+}
+
+// Returns the first property with the given name
+func (c *icalComponent) property(name string) (icalProperty, bool) {
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+	return icalProperty{}, false
+}
+
+// Returns all properties with the given name
+func (c *icalComponent) properties(name string) []icalProperty {
+	var props []icalProperty
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			props = append(props, prop)
+		}
+	}
+	return props
+}
+
+// Reads the events of an iCalendar file, expanding recurring events into
+// occurrences that overlap [from, until)
+func ReadCalendar(filename string, from, until time.Time) ([]CalendarEvent, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	defer f.Close()
+
+	events, err := parseCalendarEvents(f, from, until)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return events, nil
+}
+
+// Parses iCalendar data and expands its events
+func parseCalendarEvents(r io.Reader, from, until time.Time) ([]CalendarEvent, error) {
+	root, err := parseICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Time zones defined in the file take precedence over the system database
+	zones := make(map[string]*vtimezone)
+	for _, child := range root.Children {
+		if child.Name == "VTIMEZONE" {
+			zone, err := parseVTimezone(child)
+			if err != nil {
+				return nil, err
+			}
+			zones[zone.id] = zone
+		}
+	}
+
+	// Modified instances replace the occurrence named by RECURRENCE-ID
+	overridden := make(map[string]map[int64]bool)
+	var vevents []*icalComponent
+	for _, child := range root.Children {
+		if child.Name != "VEVENT" {
+			continue
+		}
+		vevents = append(vevents, child)
+		if rid, ok := child.property("RECURRENCE-ID"); ok {
+			uid, _ := child.property("UID")
+			t, _, err := parseICalTime(rid, zones)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", rid.Line, err)
+			}
+			if overridden[uid.Value] == nil {
+				overridden[uid.Value] = make(map[int64]bool)
+			}
+			overridden[uid.Value][t.Unix()] = true
+		}
+	}
+
+	var events []CalendarEvent
+	for _, vevent := range vevents {
+		occurrences, err := expandEvent(vevent, zones, overridden, from, until)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, occurrences...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events, nil
+}
+
+// Expands a VEVENT into its occurrences overlapping [from, until)
+func expandEvent(vevent *icalComponent, zones map[string]*vtimezone, overridden map[string]map[int64]bool,
+	from, until time.Time) ([]CalendarEvent, error) {
+	if status, ok := vevent.property("STATUS"); ok && strings.EqualFold(status.Value, "CANCELLED") {
+		return nil, nil
+	}
+
+	dtstart, ok := vevent.property("DTSTART")
+	if !ok {
+		return nil, fmt.Errorf("event without DTSTART")
+	}
+	start, allDay, err := parseICalTime(dtstart, zones)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", dtstart.Line, err)
+	}
+
+	duration, err := eventDuration(vevent, start, allDay, zones)
+	if err != nil {
+		return nil, err
+	}
+
+	base := CalendarEvent{AllDay: allDay}
+	if prop, ok := vevent.property("UID"); ok {
+		base.UID = prop.Value
+	}
+	if prop, ok := vevent.property("SUMMARY"); ok {
+		base.Summary = unescapeICalText(prop.Value)
+	}
+	if prop, ok := vevent.property("DESCRIPTION"); ok {
+		base.Description = unescapeICalText(prop.Value)
+	}
+
+	// A modified instance is a single occurrence of its own
+	_, isOverride := vevent.property("RECURRENCE-ID")
+
+	starts := []time.Time{start}
+	rrule, hasRule := vevent.property("RRULE")
+	rdates := vevent.properties("RDATE")
+	if !isOverride && (hasRule || len(rdates) > 0) {
+		base.Recurring = true
+		starts, err = recurrenceStarts(vevent, start, allDay, zones, until)
+		if err != nil {
+			if hasRule {
+				return nil, fmt.Errorf("line %d: %w", rrule.Line, err)
+			}
+			return nil, err
+		}
+	}
+
+	var occurrences []CalendarEvent
+	for _, occurrenceStart := range starts {
+		if base.Recurring && overridden[base.UID][occurrenceStart.Unix()] {
+			continue
+		}
+		occurrence := base
+		occurrence.Start = occurrenceStart
+		occurrence.End = addDuration(occurrenceStart, duration)
+		// Instantaneous events overlap the range if they start within it
+		overlaps := occurrence.End.After(from) || (occurrence.End.Equal(occurrence.Start) && !occurrence.Start.Before(from))
+		if overlaps && occurrence.Start.Before(until) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	return occurrences, nil
+}
+
+// Returns the start times of all occurrences of a recurring event before until
+func recurrenceStarts(vevent *icalComponent, start time.Time, allDay bool, zones map[string]*vtimezone,
+	until time.Time) ([]time.Time, error) {
+	var starts []time.Time
+	if prop, ok := vevent.property("RRULE"); ok {
+		rule, err := parseRRule(prop.Value, start.Location())
+		if err != nil {
+			return nil, err
+		}
+		zone := zoneFor(start, vevent, zones)
+		starts = rule.expand(start, zone, until, maxOccurrences)
+	} else {
+		starts = []time.Time{start}
+	}
+
+	for _, prop := range vevent.properties("RDATE") {
+		times, err := parseICalTimeList(prop, zones)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", prop.Line, err)
+		}
+		starts = append(starts, times...)
+	}
+
+	excluded := make(map[int64]bool)
+	for _, prop := range vevent.properties("EXDATE") {
+		times, err := parseICalTimeList(prop, zones)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", prop.Line, err)
+		}
+		for _, t := range times {
+			excluded[t.Unix()] = true
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	var result []time.Time
+	var last time.Time
+	for _, t := range starts {
+		if excluded[t.Unix()] || (!last.IsZero() && t.Equal(last)) {
+			continue
+		}
+		result = append(result, t)
+		last = t
+	}
+	return result, nil
+}
+
+// Returns the wall clock resolver for the time zone of the event's DTSTART
+func zoneFor(start time.Time, vevent *icalComponent, zones map[string]*vtimezone) wallClock {
+	if prop, ok := vevent.property("DTSTART"); ok {
+		if tzid := prop.Params["TZID"]; tzid != "" {
+			if zone, ok := zones[tzid]; ok {
+				return zone.at
+			}
+		}
+	}
+	loc := start.Location()
+	return func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, loc)
+	}
+}
+
+// Returns the length of the event from DTEND or DURATION
+func eventDuration(vevent *icalComponent, start time.Time, allDay bool, zones map[string]*vtimezone) (icalDuration, error) {
+	if prop, ok := vevent.property("DTEND"); ok {
+		end, _, err := parseICalTime(prop, zones)
+		if err != nil {
+			return icalDuration{}, fmt.Errorf("line %d: %w", prop.Line, err)
+		}
+		if allDay {
+			days := int(end.Sub(start).Round(24*time.Hour) / (24 * time.Hour))
+			return icalDuration{days: days}, nil
+		}
+		return icalDuration{exact: end.Sub(start)}, nil
+	}
+	if prop, ok := vevent.property("DURATION"); ok {
+		d, err := parseICalDuration(prop.Value)
+		if err != nil {
+			return icalDuration{}, fmt.Errorf("line %d: %w", prop.Line, err)
+		}
+		return d, nil
+	}
+	// All-day events without an end last one day
+	if allDay {
+		return icalDuration{days: 1}, nil
+	}
+	return icalDuration{}, nil
+}
+
+// Represents an iCalendar duration; days are nominal and follow the wall clock
+type icalDuration struct {
+	days  int
+	exact time.Duration
+}
+
+// Adds the duration to the time
+func addDuration(t time.Time, d icalDuration) time.Time {
+	return t.AddDate(0, 0, d.days).Add(d.exact)
+}
+
+// Parses a duration such as P1D, PT1H30M or P2W
+func parseICalDuration(value string) (icalDuration, error) {
+	s := value
+	sign := 1
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(s, "P") {
+		return icalDuration{}, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	var d icalDuration
+	inTime := false
+	number := ""
+	for _, r := range s {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			number += string(r)
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return icalDuration{}, fmt.Errorf("invalid duration %q", value)
+			}
+			number = ""
+			switch {
+			case r == 'W' && !inTime:
+				d.days += 7 * n
+			case r == 'D' && !inTime:
+				d.days += n
+			case r == 'H' && inTime:
+				d.exact += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				d.exact += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				d.exact += time.Duration(n) * time.Second
+			default:
+				return icalDuration{}, fmt.Errorf("invalid duration %q", value)
+			}
+		}
+	}
+	if number != "" {
+		return icalDuration{}, fmt.Errorf("invalid duration %q", value)
+	}
+
+	d.days *= sign
+	d.exact *= time.Duration(sign)
+	return d, nil
+}
+
+// Parses a DATE or DATE-TIME property value, reporting whether it is a date
+func parseICalTime(prop icalProperty, zones map[string]*vtimezone) (time.Time, bool, error) {
+	return parseICalTimeValue(prop.Value, prop.Params, zones)
+}
+
+// Parses a comma separated list of DATE or DATE-TIME values
+func parseICalTimeList(prop icalProperty, zones map[string]*vtimezone) ([]time.Time, error) {
+	var times []time.Time
+	for _, value := range strings.Split(prop.Value, ",") {
+		if strings.Contains(value, "/") {
+			// PERIOD values start with the start time
+			value = strings.SplitN(value, "/", 2)[0]
+		}
+		t, _, err := parseICalTimeValue(value, prop.Params, zones)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// Parses a single DATE or DATE-TIME value using the TZID parameter
+func parseICalTimeValue(value string, params map[string]string, zones map[string]*vtimezone) (time.Time, bool, error) {
+	// All-day dates are floating and taken in the local time zone
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+
+	wall, err := time.Parse("20060102T150405", value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+
+	tzid := params["TZID"]
+	if tzid == "" {
+		// Floating times are taken in the local time zone
+		return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, time.Local), false, nil
+	}
+	if zone, ok := zones[tzid]; ok {
+		return zone.at(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second()), false, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unknown time zone %q", tzid)
+	}
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc), false, nil
+}
+
+// Parses iCalendar content lines into the VCALENDAR component
+func parseICal(r io.Reader) (*icalComponent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*icalComponent
+	var root *icalComponent
+
+	for _, line := range lines {
+		prop, err := parseICalLine(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		prop.Line = line.number
+
+		switch prop.Name {
+		case "BEGIN":
+			component := &icalComponent{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, component)
+			} else if root == nil {
+				root = component
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", line.number, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside of a component", line.number, prop.Name)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("not an iCalendar file (missing BEGIN:VCALENDAR)")
+	}
+	return root, nil
+}
+
+// Represents an unfolded content line and the line number it started on
+type icalLine struct {
+	text   string
+	number int
+}
+
+// Joins folded lines, which continue with a leading space or tab
+func unfoldICalLines(r io.Reader) ([]icalLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []icalLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, icalLine{text: text, number: number})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// Parses NAME;PARAM=value;PARAM="quoted":value
+func parseICalLine(line string) (icalProperty, error) {
+	prop := icalProperty{Params: make(map[string]string)}
+
+	// The value starts at the first colon outside of quotes
+	inQuotes := false
+	split := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			split = i
+			break
+		}
+	}
+	if split < 0 {
+		return prop, fmt.Errorf("invalid content line %q", line)
+	}
+
+	head := line[:split]
+	prop.Value = line[split+1:]
+
+	parts := splitOutsideQuotes(head, ';')
+	prop.Name = strings.ToUpper(parts[0])
+	if prop.Name == "" {
+		return prop, fmt.Errorf("invalid content line %q", line)
+	}
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return prop, fmt.Errorf("invalid parameter %q", param)
+		}
+		prop.Params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+
+	return prop, nil
+}
+
+// Splits s at sep characters that are not inside double quotes
+func splitOutsideQuotes(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Unescapes a TEXT value
+func unescapeICalText(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// Resolves a wall clock time in a time zone to an instant
+type wallClock func(year int, month time.Month, day, hour, min, sec int) time.Time
+
+// Represents a VTIMEZONE definition
+type vtimezone struct {
+	id          string
+	observances []observance
+}
+
+// Represents a STANDARD or DAYLIGHT block of a VTIMEZONE
+type observance struct {
+	name       string
+	start      time.Time // Onset as wall clock time in UTC (offsets not applied)
+	offsetFrom int       // Seconds east of UTC before the onset
+	offsetTo   int       // Seconds east of UTC from the onset
+	rule       *rrule
+	rdates     []time.Time
+}
+
+// Parses a VTIMEZONE component
+func parseVTimezone(component *icalComponent) (*vtimezone, error) {
+	tzid, ok := component.property("TZID")
+	if !ok {
+		return nil, fmt.Errorf("VTIMEZONE without TZID")
+	}
+	zone := &vtimezone{id: tzid.Value}
+
+	for _, child := range component.Children {
+		if child.Name != "STANDARD" && child.Name != "DAYLIGHT" {
+			continue
+		}
+
+		o := observance{name: child.Name}
+		if prop, ok := child.property("TZNAME"); ok {
+			o.name = prop.Value
+		}
+
+		dtstart, ok := child.property("DTSTART")
+		if !ok {
+			return nil, fmt.Errorf("VTIMEZONE %s: %s without DTSTART", zone.id, child.Name)
+		}
+		start, err := time.Parse("20060102T150405", dtstart.Value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid DTSTART %q", dtstart.Line, dtstart.Value)
+		}
+		o.start = start
+
+		for _, field := range []struct {
+			name string
+			dst  *int
+		}{{"TZOFFSETFROM", &o.offsetFrom}, {"TZOFFSETTO", &o.offsetTo}} {
+			prop, ok := child.property(field.name)
+			if !ok {
+				return nil, fmt.Errorf("VTIMEZONE %s: %s without %s", zone.id, child.Name, field.name)
+			}
+			offset, err := parseUTCOffset(prop.Value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", prop.Line, err)
+			}
+			*field.dst = offset
+		}
+
+		if prop, ok := child.property("RRULE"); ok {
+			rule, err := parseRRule(prop.Value, time.UTC)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", prop.Line, err)
+			}
+			// UNTIL in UTC is compared against wall clock onsets
+			o.rule = rule
+		}
+		for _, prop := range child.properties("RDATE") {
+			for _, value := range strings.Split(prop.Value, ",") {
+				t, err := time.Parse("20060102T150405", strings.TrimSuffix(value, "Z"))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid RDATE %q", prop.Line, value)
+				}
+				o.rdates = append(o.rdates, t)
+			}
+		}
+
+		zone.observances = append(zone.observances, o)
+	}
+
+	if len(zone.observances) == 0 {
+		return nil, fmt.Errorf("VTIMEZONE %s has no STANDARD or DAYLIGHT component", zone.id)
+	}
+	return zone, nil
+}
+
+// Resolves a wall clock time in the zone using the latest observance onset
+func (z *vtimezone) at(year int, month time.Month, day, hour, min, sec int) time.Time {
+	wall := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+
+	var latest time.Time
+	var active *observance
+	for i := range z.observances {
+		o := &z.observances[i]
+		if onset, ok := o.lastOnset(wall); ok && (active == nil || onset.After(latest)) {
+			latest = onset
+			active = o
+		}
+	}
+
+	offset := 0
+	name := z.id
+	if active != nil {
+		offset = active.offsetTo
+		name = active.name
+	} else {
+		// Before the first onset the earliest observance's previous offset applies
+		earliest := &z.observances[0]
+		for i := range z.observances {
+			if z.observances[i].start.Before(earliest.start) {
+				earliest = &z.observances[i]
+			}
+		}
+		offset = earliest.offsetFrom
+	}
+
+	return time.Date(year, month, day, hour, min, sec, 0, time.FixedZone(name, offset))
+}
+
+// Returns the latest onset at or before the wall clock time
+func (o *observance) lastOnset(wall time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	consider := func(t time.Time) {
+		if !t.After(wall) && (!found || t.After(latest)) {
+			latest = t
+			found = true
+		}
+	}
+
+	consider(o.start)
+	for _, t := range o.rdates {
+		consider(t)
+	}
+	if o.rule != nil && !o.start.After(wall) {
+		utc := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+			return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+		}
+		for _, t := range o.rule.expand(o.start, utc, wall.Add(time.Second), maxOccurrences) {
+			consider(t)
+		}
+	}
+	return latest, found
+}
+
+// Parses a UTC offset such as +0900 or -053000 into seconds
+func parseUTCOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	sign := 1
+	switch value[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	hours, err1 := strconv.Atoi(value[1:3])
+	minutes, err2 := strconv.Atoi(value[3:5])
+	seconds := 0
+	var err3 error
+	if len(value) == 7 {
+		seconds, err3 = strconv.Atoi(value[5:7])
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	return sign * (hours*3600 + minutes*60 + seconds), nil
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Wraps calendar components in a VCALENDAR with CRLF line endings
+func calendar(components ...string) string {
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//test//EN"}
+	for _, component := range components {
+		lines = append(lines, strings.Split(strings.TrimSpace(component), "\n")...)
+	}
+	lines = append(lines, "END:VCALENDAR")
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+const easternTimezone = `
+BEGIN:VTIMEZONE
+TZID:Eastern
+BEGIN:STANDARD
+DTSTART:19701101T020000
+TZOFFSETFROM:-0400
+TZOFFSETTO:-0500
+RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU
+TZNAME:EST
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:19700308T020000
+TZOFFSETFROM:-0500
+TZOFFSETTO:-0400
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU
+TZNAME:EDT
+END:DAYLIGHT
+END:VTIMEZONE`
+
+func TestParseCalendarEvents(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// All-day events are shown as dates, others as UTC times
+	format := func(events []CalendarEvent) []string {
+		var out []string
+		for _, event := range events {
+			if event.AllDay {
+				out = append(out, event.Start.Format("2006-01-02")+".."+event.End.Format("2006-01-02"))
+			} else {
+				out = append(out, event.Start.UTC().Format(time.RFC3339)+".."+event.End.UTC().Format(time.RFC3339))
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "all-day event without end lasts one day",
+			input: calendar(`
+BEGIN:VEVENT
+UID:kodomo
+DTSTART;VALUE=DATE:20240505
+SUMMARY:Children's Day
+END:VEVENT`),
+			want: []string{"2024-05-05..2024-05-06"},
+		},
+		{
+			name: "multi-day all-day event has exclusive end",
+			input: calendar(`
+BEGIN:VEVENT
+UID:obon
+DTSTART;VALUE=DATE:20240813
+DTEND;VALUE=DATE:20240817
+END:VEVENT`),
+			want: []string{"2024-08-13..2024-08-17"},
+		},
+		{
+			name: "VTIMEZONE switches between standard and daylight time",
+			input: calendar(easternTimezone, `
+BEGIN:VEVENT
+UID:winter
+DTSTART;TZID=Eastern:20240115T090000
+DURATION:PT1H
+END:VEVENT
+BEGIN:VEVENT
+UID:summer
+DTSTART;TZID=Eastern:20240715T090000
+DTEND;TZID=Eastern:20240715T093000
+END:VEVENT`),
+			want: []string{
+				"2024-01-15T14:00:00Z..2024-01-15T15:00:00Z",
+				"2024-07-15T13:00:00Z..2024-07-15T13:30:00Z",
+			},
+		},
+		{
+			name: "recurrence keeps wall clock time across DST change",
+			input: calendar(easternTimezone, `
+BEGIN:VEVENT
+UID:standup
+DTSTART;TZID=Eastern:20240309T090000
+RRULE:FREQ=DAILY;COUNT=2
+END:VEVENT`),
+			want: []string{
+				"2024-03-09T14:00:00Z..2024-03-09T14:00:00Z",
+				"2024-03-10T13:00:00Z..2024-03-10T13:00:00Z",
+			},
+		},
+		{
+			name: "TZID from the time zone database",
+			input: calendar(`
+BEGIN:VEVENT
+UID:tokyo
+DTSTART;TZID=Asia/Tokyo:20240601T090000
+END:VEVENT`),
+			want: []string{"2024-06-01T00:00:00Z..2024-06-01T00:00:00Z"},
+		},
+		{
+			name: "weekly rule with exception date",
+			input: calendar(`
+BEGIN:VEVENT
+UID:weekly
+DTSTART:20240101T100000Z
+DTEND:20240101T110000Z
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4
+EXDATE:20240103T100000Z
+END:VEVENT`),
+			want: []string{
+				"2024-01-01T10:00:00Z..2024-01-01T11:00:00Z",
+				"2024-01-08T10:00:00Z..2024-01-08T11:00:00Z",
+				"2024-01-10T10:00:00Z..2024-01-10T11:00:00Z",
+			},
+		},
+		{
+			name: "yearly rule with ordinal weekday and until",
+			input: calendar(`
+BEGIN:VEVENT
+UID:thanksgiving
+DTSTART;VALUE=DATE:20231123
+RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;UNTIL=20251231
+END:VEVENT`),
+			want: []string{"2024-11-28..2024-11-29", "2025-11-27..2025-11-28"},
+		},
+		{
+			name: "monthly rule on the last day of the month",
+			input: calendar(`
+BEGIN:VEVENT
+UID:month-end
+DTSTART:20240131T170000Z
+RRULE:FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3
+END:VEVENT`),
+			want: []string{
+				"2024-01-31T17:00:00Z..2024-01-31T17:00:00Z",
+				"2024-02-29T17:00:00Z..2024-02-29T17:00:00Z",
+				"2024-03-31T17:00:00Z..2024-03-31T17:00:00Z",
+			},
+		},
+		{
+			name: "modified instance replaces its occurrence",
+			input: calendar(`
+BEGIN:VEVENT
+UID:sync
+DTSTART:20240101T100000Z
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:sync
+RECURRENCE-ID:20240102T100000Z
+DTSTART:20240102T150000Z
+END:VEVENT`),
+			want: []string{
+				"2024-01-01T10:00:00Z..2024-01-01T10:00:00Z",
+				"2024-01-02T15:00:00Z..2024-01-02T15:00:00Z",
+				"2024-01-03T10:00:00Z..2024-01-03T10:00:00Z",
+			},
+		},
+		{
+			name: "cancelled events and events outside the range are ignored",
+			input: calendar(`
+BEGIN:VEVENT
+UID:cancelled
+DTSTART:20240601T100000Z
+STATUS:CANCELLED
+END:VEVENT
+BEGIN:VEVENT
+UID:old
+DTSTART:20230601T100000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:far
+DTSTART:20270601T100000Z
+END:VEVENT`),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := parseCalendarEvents(strings.NewReader(tt.input), from, until)
+			if err != nil {
+				t.Fatalf("parseCalendarEvents() error = %v", err)
+			}
+			if got := format(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCalendarEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCalendarEvents_Text(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:launch\r\nDTSTART:20240601T100000Z\r\n" +
+		"SUMMARY:Launch day\\, finally\r\nDESCRIPTION:First line\\nsecond \r\n line\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	events, err := parseCalendarEvents(strings.NewReader(input), time.Time{}, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("parseCalendarEvents() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("parseCalendarEvents() returned %d events, want 1", len(events))
+	}
+	if got, want := events[0].Summary, "Launch day, finally"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	if got, want := events[0].Description, "First line\nsecond line"; got != want {
+		t.Errorf("Description = %q, want %q", got, want)
+	}
+}
+
+func TestParseCalendarEvents_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "not a calendar",
+			input:   "BEGIN:VCARD\r\nEND:VCARD\r\n",
+			wantErr: "missing BEGIN:VCALENDAR",
+		},
+		{
+			name:    "unterminated component",
+			input:   "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n",
+			wantErr: "missing END:VEVENT",
+		},
+		{
+			name:    "unknown time zone",
+			input:   calendar("BEGIN:VEVENT\nDTSTART;TZID=Mars/Olympus:20240101T090000\nEND:VEVENT"),
+			wantErr: `unknown time zone "Mars/Olympus"`,
+		},
+		{
+			name:    "unsupported recurrence",
+			input:   calendar("BEGIN:VEVENT\nDTSTART:20240101T090000Z\nRRULE:FREQ=HOURLY\nEND:VEVENT"),
+			wantErr: `unsupported RRULE frequency "HOURLY"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCalendarEvents(strings.NewReader(tt.input), time.Time{}, time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseCalendarEvents() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_Calendars(t *testing.T) {
+	dir := t.TempDir()
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("20060102")
+
+	holidays := calendar("BEGIN:VEVENT\nUID:holiday\nDTSTART;VALUE=DATE:" + tomorrow + "\nSUMMARY:Holiday\nEND:VEVENT")
+	campaign := calendar("BEGIN:VEVENT\nUID:launch\nDTSTART;VALUE=DATE:" + tomorrow +
+		"\nRRULE:FREQ=DAILY;COUNT=2\nSUMMARY:Launch\nDESCRIPTION:Now available\nEND:VEVENT")
+	configYAML := `
+blackouts:
+  - name: holidays
+    calendar: holidays.ics
+calendar_posts:
+  - file: cal/campaign.ics
+    content: both
+    at: "10:30"
+    enabled: true
+posts: []
+`
+	if err := os.Mkdir(filepath.Join(dir, "cal"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"holidays.ics":     holidays,
+		"cal/campaign.ics": campaign,
+		"config.yaml":      configYAML,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if len(cfg.Posts) != 2 {
+		t.Fatalf("Load() generated %d posts, want 2", len(cfg.Posts))
+	}
+	post := cfg.Posts[0]
+	if want := "launch@" + tomorrow; post.ID != want {
+		t.Errorf("post ID = %q, want %q", post.ID, want)
+	}
+	if want := "Launch\n\nNow available"; post.Content != want {
+		t.Errorf("post content = %q, want %q", post.Content, want)
+	}
+	if got := post.ScheduledAt.Format("15:04"); got != "10:30" {
+		t.Errorf("post scheduled at %s, want 10:30", got)
+	}
+
+	if blackout := cfg.BlackoutFor(post, post.ScheduledAt); blackout == nil || blackout.Name != "holidays" {
+		t.Errorf("BlackoutFor() = %v, want holidays", blackout)
+	}
+	if blackout := cfg.BlackoutFor(cfg.Posts[1], cfg.Posts[1].ScheduledAt); blackout != nil {
+		t.Errorf("BlackoutFor() = %v, want nil", blackout)
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Represents how posts exceeding a limit are handled
+type OverflowPolicy string
+
+const (
+	OverflowDefer  OverflowPolicy = "defer"  // Move to the next hour with capacity
+	OverflowReject OverflowPolicy = "reject" // Do not post
+)
+
+// Represents posting caps for a single account (0 means unlimited)
+type AccountLimit struct {
+	PerHour int `yaml:"per_hour,omitempty"` // Maximum posts per clock hour (0 for no cap)
+	PerDay  int `yaml:"per_day,omitempty"`  // Maximum posts per calendar day (0 for no cap)
+}
+
+// Represents posting caps per account, counted per clock hour and calendar day
+type Limits struct {
+	PerHour  int                     `yaml:"per_hour,omitempty"` // Maximum posts per clock hour (0 for no cap)
+	PerDay   int                     `yaml:"per_day,omitempty"`  // Maximum posts per calendar day (0 for no cap)
+	Overflow OverflowPolicy          `yaml:"overflow,omitempty"` // What happens to posts over the hourly cap
+	Accounts map[string]AccountLimit `yaml:"accounts,omitempty"` // Per-account overrides
+}
+
+// Returns the caps for the given account
+func (l *Limits) For(account string) AccountLimit {
+	if l == nil {
+		return AccountLimit{}
+	}
+	if limit, ok := l.Accounts[account]; ok {
+		return limit
+	}
+	return AccountLimit{PerHour: l.PerHour, PerDay: l.PerDay}
+}
+
+// Returns the configured overflow policy, defaulting to defer
+func (l *Limits) OverflowOrDefault() OverflowPolicy {
+	if l == nil || l.Overflow == "" {
+		return OverflowDefer
+	}
+	return l.Overflow
+}
+
+// Checks the limit settings for errors
+func (l *Limits) Validate() error {
+	switch l.OverflowOrDefault() {
+	case OverflowDefer, OverflowReject:
+	default:
+		return fmt.Errorf("limits: unknown overflow policy %q (want defer or reject)", l.Overflow)
+	}
+	if l.PerHour < 0 || l.PerDay < 0 {
+		return fmt.Errorf("limits: caps must not be negative")
+	}
+	for account, limit := range l.Accounts {
+		if limit.PerHour < 0 || limit.PerDay < 0 {
+			return fmt.Errorf("limits: caps for account %s must not be negative", AccountName(account))
+		}
+	}
+	return nil
+}
+
+// Represents a period in which an account has more posts than allowed
+type LimitViolation struct {
+	Account string
+	Period  string // "hour" or "day"
+	Start   time.Time
+	Count   int
+	Limit   int
+	Posts   []Post
+}
+
+// Returns a human readable description of the violation
+func (lv LimitViolation) String() string {
+	format := "2006-01-02 15:04"
+	if lv.Period == "day" {
+		format = "2006-01-02"
+	}
+	return fmt.Sprintf("account %s has %d posts in the %s starting %s (limit %d)",
+		AccountName(lv.Account), lv.Count, lv.Period, lv.Start.Format(format), lv.Limit)
+}
+
+// Returns the position of the last post in the period, which exceeds the cap
+func (lv LimitViolation) Source() Source {
+	var last Post
+	for _, post := range lv.Posts {
+		if last.ScheduledAt.IsZero() || post.ScheduledAt.After(last.ScheduledAt) {
+			last = post
+		}
+	}
+	return last.sourceOf("scheduled_at")
+}
+
+// Returns periods in which enabled future posts exceed the configured caps
+func (c *Config) LimitViolations(now time.Time) []LimitViolation {
+	if c.Limits == nil {
+		return nil
+	}
+
+	type bucket struct {
+		account string
+		period  string
+		start   time.Time
+	}
+	buckets := make(map[bucket][]Post)
+	for _, post := range c.Posts {
+		// Test posts run immediately and past posts are history
+		if !post.Enabled || post.Test || !post.ScheduledAt.After(now) {
+			continue
+		}
+		t := post.ScheduledAt.In(now.Location())
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		hour := t.Truncate(time.Hour)
+		buckets[bucket{post.Account, "hour", hour}] = append(buckets[bucket{post.Account, "hour", hour}], post)
+		buckets[bucket{post.Account, "day", day}] = append(buckets[bucket{post.Account, "day", day}], post)
+	}
+
+	var violations []LimitViolation
+	for b, posts := range buckets {
+		limit := c.Limits.For(b.account)
+		max := limit.PerHour
+		if b.period == "day" {
+			max = limit.PerDay
+		}
+		if max > 0 && len(posts) > max {
+			violations = append(violations, LimitViolation{
+				Account: b.account,
+				Period:  b.period,
+				Start:   b.start,
+				Count:   len(posts),
+				Limit:   max,
+				Posts:   posts,
+			})
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		if !violations[i].Start.Equal(violations[j].Start) {
+			return violations[i].Start.Before(violations[j].Start)
+		}
+		if violations[i].Account != violations[j].Account {
+			return violations[i].Account < violations[j].Account
+		}
+		return violations[i].Period < violations[j].Period
+	})
+	return violations
+}
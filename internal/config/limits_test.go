@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_LimitViolations(t *testing.T) {
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	config := Config{
+		Limits: &Limits{
+			PerHour:  2,
+			PerDay:   3,
+			Accounts: map[string]AccountLimit{"brand": {PerHour: 1}},
+		},
+		Posts: []Post{
+			{ID: "a", Content: "A", ScheduledAt: at(9, 0), Enabled: true},
+			{ID: "b", Content: "B", ScheduledAt: at(9, 10), Enabled: true},
+			{ID: "c", Content: "C", ScheduledAt: at(9, 20), Enabled: true},
+			{ID: "d", Content: "D", ScheduledAt: at(12, 0), Enabled: true},
+			{ID: "e", Content: "E", ScheduledAt: at(9, 0), Enabled: true, Account: "brand"},
+			{ID: "f", Content: "F", ScheduledAt: at(9, 30), Enabled: true, Account: "brand"},
+			{ID: "g", Content: "G", ScheduledAt: at(9, 40), Enabled: false, Account: "brand"},
+		},
+	}
+
+	violations := config.LimitViolations(now)
+
+	want := []string{
+		"account default has 4 posts in the day starting 2024-06-01 (limit 3)",
+		"account default has 3 posts in the hour starting 2024-06-01 09:00 (limit 2)",
+		"account brand has 2 posts in the hour starting 2024-06-01 09:00 (limit 1)",
+	}
+	got := make(map[string]bool)
+	for _, v := range violations {
+		got[v.String()] = true
+	}
+	if len(violations) != len(want) {
+		t.Errorf("LimitViolations() returned %d violations, want %d: %v", len(violations), len(want), violations)
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("LimitViolations() missing %q", w)
+		}
+	}
+}
+
+func TestConfig_ValidateLimits(t *testing.T) {
+	future := time.Now().Add(time.Hour).Truncate(time.Hour).Add(time.Hour)
+	posts := []Post{
+		{ID: "a", Content: "A", ScheduledAt: future, Enabled: true},
+		{ID: "b", Content: "B", ScheduledAt: future.Add(time.Minute), Enabled: true},
+	}
+
+	rejectConfig := Config{Limits: &Limits{PerHour: 1, Overflow: OverflowReject}, Posts: posts}
+	if err := rejectConfig.Validate(); err == nil {
+		t.Errorf("Validate() expected error for exceeded limit with reject policy")
+	}
+
+	deferConfig := Config{Limits: &Limits{PerHour: 1}, Posts: posts}
+	if err := deferConfig.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+
+	badConfig := Config{Limits: &Limits{Overflow: "queue"}, Posts: posts}
+	if err := badConfig.Validate(); err == nil {
+		t.Errorf("Validate() expected error for unknown overflow policy")
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/content"
+)
+
+// Represents a link in the content of an upcoming post
+type Link struct {
+	URL    string
+	Label  string // Post or queue item the link appears in
+	Source Source
+}
+
+// Returns the links of enabled posts scheduled after now and of enabled queues
+//
+// Every text a post may be published with is included, rendered at its
+// scheduled time; texts that fail to render are left to Check.
+func (c *Config) Links(now time.Time) []Link {
+	var links []Link
+	add := func(text, label string, source Source) {
+		seen := make(map[string]bool)
+		for _, url := range content.URLs(text) {
+			if !seen[url] {
+				seen[url] = true
+				links = append(links, Link{URL: url, Label: label, Source: source})
+			}
+		}
+	}
+
+	// Posts are labeled by identifier, as in the run state and approvals
+	for _, post := range c.Posts {
+		if !post.Enabled || !post.ScheduledAt.After(now) {
+			continue
+		}
+		for _, text := range post.texts("post " + post.Identifier()) {
+			if rendered, err := c.RenderContent(text.post, post.ScheduledAt); err == nil {
+				add(rendered, text.label, post.sourceOf(text.field))
+			}
+		}
+	}
+	for _, queue := range c.Queues {
+		if !queue.Enabled {
+			continue
+		}
+		for i, item := range queue.Items {
+			if rendered, err := c.RenderContent(queue.Post(item, now), now); err == nil {
+				add(rendered, fmt.Sprintf("queue %s: item %d", queue.Name, i+1), queue.source)
+			}
+		}
+	}
+	return links
+}
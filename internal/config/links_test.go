@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConfig_Links(t *testing.T) {
+	now := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	cfg := &Config{
+		Vars: map[string]string{"site": "https://example.com"},
+		Posts: []Post{
+			{ID: "past", Content: "https://example.com/old", ScheduledAt: now.Add(-time.Hour), Enabled: true},
+			{ID: "disabled", Content: "https://example.com/draft", ScheduledAt: now.Add(time.Hour)},
+			{ID: "launch", Content: "{{.Vars.site}}/launch and again {{.Vars.site}}/launch", Template: true,
+				ScheduledAt: now.Add(time.Hour), Enabled: true},
+			{ID: "hello", Lang: "en", Localized: map[string]string{"en": "https://example.com/en", "ja": "https://example.com/ja"},
+				ScheduledAt: now.Add(time.Hour), Enabled: true},
+			{Content: "https://example.com/unnamed", ScheduledAt: now.Add(2 * time.Hour), Enabled: true},
+		},
+		Queues: []Queue{{Name: "tips", Enabled: true, Items: []QueueItem{{Content: "See https://example.com/tips"}}}},
+	}
+
+	unnamed := cfg.Posts[4].Identifier()
+
+	var got []string
+	for _, link := range cfg.Links(now) {
+		got = append(got, link.Label+" "+link.URL)
+	}
+	want := []string{
+		"post launch https://example.com/launch",
+		"post hello (en) https://example.com/en",
+		"post hello (ja) https://example.com/ja",
+		"post " + unnamed + " https://example.com/unnamed",
+		"queue tips: item 1 https://example.com/tips",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Links() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/zinrai/x-scheduler/internal/content"
+)
+
+// Represents style rules that post content is checked against by -validate
+//
+// Every rule reports with its own severity, defaulting to warning, so that
+// brand guidelines can fail validation while matters of taste only inform.
+type Lint struct {
+	Rules              []LintRule `yaml:"rules,omitempty"`               // Banned words and required spellings
+	Hashtags           *LintCount `yaml:"hashtags,omitempty"`            // Maximum number of hashtags per post
+	Mentions           *LintCount `yaml:"mentions,omitempty"`            // Maximum number of mentions per post
+	TrailingWhitespace Severity   `yaml:"trailing_whitespace,omitempty"` // Report spaces at line ends with this severity
+	AllCaps            *LintCaps  `yaml:"all_caps,omitempty"`            // Report words written in capitals
+}
+
+// Represents a pattern that must not appear in content
+//
+// Rules with a replacement enforce a spelling instead: matches that differ
+// from their replacement are reported with the replacement as the fix.
+type LintRule struct {
+	Pattern  string   `yaml:"pattern"`            // Regular expression in RE2 syntax, e.g. "(?i)\\bgithub\\b"
+	Replace  string   `yaml:"replace,omitempty"`  // Required spelling of matches, may refer to groups as $1
+	Message  string   `yaml:"message,omitempty"`  // Explanation shown with each match
+	Severity Severity `yaml:"severity,omitempty"` // Severity of matches (default: warning)
+}
+
+// Represents a cap on the number of hashtags or mentions
+type LintCount struct {
+	Max      int      `yaml:"max"`                // Maximum number per post
+	Severity Severity `yaml:"severity,omitempty"` // Severity of posts over the cap (default: warning)
+}
+
+// Represents the detection of shouting in capitals
+type LintCaps struct {
+	MinLength int      `yaml:"min_length,omitempty"` // Shortest word reported (default: 4)
+	Allow     []string `yaml:"allow,omitempty"`      // Words that may be written in capitals, e.g. acronyms
+	Severity  Severity `yaml:"severity,omitempty"`   // Severity of words in capitals (default: warning)
+}
+
+// Represents a lint rule violated by post content
+type LintProblem struct {
+	Severity Severity
+	Rule     string // Name of the rule, e.g. "hashtags"
+	Message  string
+	Fix      string // Suggested change, if there is one
+}
+
+// Returns "rule: message; fix: ..."
+func (p LintProblem) String() string {
+	if p.Fix == "" {
+		return fmt.Sprintf("%s: %s", p.Rule, p.Message)
+	}
+	return fmt.Sprintf("%s: %s; fix: %s", p.Rule, p.Message, p.Fix)
+}
+
+// Returns the severity, defaulting to warning
+func severityOrDefault(severity Severity) Severity {
+	if severity == "" {
+		return SeverityWarning
+	}
+	return severity
+}
+
+// Checks a severity setting for errors
+func validateSeverity(severity Severity) error {
+	switch severityOrDefault(severity) {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return nil
+	}
+	return fmt.Errorf("unknown severity %q (want error, warning or info)", severity)
+}
+
+// Checks the lint settings for errors
+func (l *Lint) Validate() error {
+	for i, rule := range l.Rules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("lint: rule %d: pattern is required", i+1)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("lint: rule %d: invalid pattern: %w", i+1, err)
+		}
+		if err := validateSeverity(rule.Severity); err != nil {
+			return fmt.Errorf("lint: rule %d: %w", i+1, err)
+		}
+	}
+	for name, count := range map[string]*LintCount{"hashtags": l.Hashtags, "mentions": l.Mentions} {
+		if count == nil {
+			continue
+		}
+		if count.Max < 0 {
+			return fmt.Errorf("lint: %s: max must not be negative", name)
+		}
+		if err := validateSeverity(count.Severity); err != nil {
+			return fmt.Errorf("lint: %s: %w", name, err)
+		}
+	}
+	if l.TrailingWhitespace != "" {
+		if err := validateSeverity(l.TrailingWhitespace); err != nil {
+			return fmt.Errorf("lint: trailing_whitespace: %w", err)
+		}
+	}
+	if l.AllCaps != nil {
+		if l.AllCaps.MinLength < 0 {
+			return fmt.Errorf("lint: all_caps: min_length must not be negative")
+		}
+		if err := validateSeverity(l.AllCaps.Severity); err != nil {
+			return fmt.Errorf("lint: all_caps: %w", err)
+		}
+	}
+	return nil
+}
+
+// Returns the problems found in text, in rule order
+//
+// The settings must be valid; a nil Lint finds nothing.
+func (l *Lint) Problems(text string) []LintProblem {
+	if l == nil {
+		return nil
+	}
+
+	var problems []LintProblem
+	for _, rule := range l.Rules {
+		problems = append(problems, rule.problems(text)...)
+	}
+	if problem, ok := countProblem("hashtags", "#", content.Hashtags(text), l.Hashtags); ok {
+		problems = append(problems, problem)
+	}
+	if problem, ok := countProblem("mentions", "@", content.Mentions(text), l.Mentions); ok {
+		problems = append(problems, problem)
+	}
+	if l.TrailingWhitespace != "" {
+		for i, line := range strings.Split(text, "\n") {
+			if strings.TrimRight(line, " \t　") != line {
+				problems = append(problems, LintProblem{
+					Severity: l.TrailingWhitespace,
+					Rule:     "trailing_whitespace",
+					Message:  fmt.Sprintf("line %d ends with whitespace", i+1),
+					Fix:      "remove it",
+				})
+			}
+		}
+	}
+	if l.AllCaps != nil {
+		problems = append(problems, l.AllCaps.problems(text)...)
+	}
+	return problems
+}
+
+// Returns a problem for every match of the rule
+func (r LintRule) problems(text string) []LintProblem {
+	pattern := regexp.MustCompile(r.Pattern)
+	var problems []LintProblem
+	for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		problem := LintProblem{Severity: severityOrDefault(r.Severity), Rule: "banned"}
+		if r.Replace != "" {
+			problem.Rule = "spelling"
+			replacement := string(pattern.ExpandString(nil, r.Replace, text, loc))
+			if replacement == match {
+				continue
+			}
+			problem.Message = fmt.Sprintf("%q should be written %q", match, replacement)
+			problem.Fix = fmt.Sprintf("replace %q with %q", match, replacement)
+		} else {
+			problem.Message = fmt.Sprintf("%q is not allowed", match)
+		}
+		if r.Message != "" {
+			problem.Message += ": " + r.Message
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// Returns a problem if there are more entities than the cap allows
+func countProblem(rule, prefix string, found []string, count *LintCount) (LintProblem, bool) {
+	if count == nil || len(found) <= count.Max {
+		return LintProblem{}, false
+	}
+	excess := found[count.Max:]
+	for i := range excess {
+		excess[i] = prefix + excess[i]
+	}
+	return LintProblem{
+		Severity: severityOrDefault(count.Severity),
+		Rule:     rule,
+		Message:  fmt.Sprintf("%d %s (maximum %d)", len(found), rule, count.Max),
+		Fix:      "remove " + strings.Join(excess, " "),
+	}, true
+}
+
+// Returns a problem for every word written in capitals
+func (c *LintCaps) problems(text string) []LintProblem {
+	minLength := c.MinLength
+	if minLength == 0 {
+		minLength = 4
+	}
+	allowed := make(map[string]bool, len(c.Allow))
+	for _, word := range c.Allow {
+		allowed[word] = true
+	}
+
+	var problems []LintProblem
+	for _, word := range content.Words(text) {
+		// Hashtags and usernames are names, not shouting
+		if strings.HasPrefix(word, "#") || strings.HasPrefix(word, "@") || allowed[word] || !shouting(word, minLength) {
+			continue
+		}
+		fix := string([]rune(word)[:1]) + strings.ToLower(string([]rune(word)[1:]))
+		problems = append(problems, LintProblem{
+			Severity: severityOrDefault(c.Severity),
+			Rule:     "all_caps",
+			Message:  fmt.Sprintf("%q is written in capitals", word),
+			Fix:      fmt.Sprintf("write %q", fix),
+		})
+	}
+	return problems
+}
+
+// Reports whether word has at least minLength letters, all of them upper case
+func shouting(word string, minLength int) bool {
+	letters := 0
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if !unicode.IsUpper(r) {
+			return false
+		}
+		letters++
+	}
+	return letters >= minLength
+}
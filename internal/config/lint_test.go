@@ -0,0 +1,140 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLint_Problems(t *testing.T) {
+	lint := &Lint{
+		Rules: []LintRule{
+			{Pattern: `(?i)\bcheap\b`, Message: "say affordable", Severity: SeverityError},
+			{Pattern: `(?i)\bgithub\b`, Replace: "GitHub"},
+			{Pattern: `(?i)\bx-scheduler\b`, Replace: "x-scheduler", Severity: SeverityInfo},
+		},
+		Hashtags:           &LintCount{Max: 2},
+		Mentions:           &LintCount{Max: 1, Severity: SeverityInfo},
+		TrailingWhitespace: SeverityWarning,
+		AllCaps:            &LintCaps{Allow: []string{"NASA"}},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "clean",
+			text: "x-scheduler is on GitHub, used by NASA and @gophers #go",
+		},
+		{
+			name: "banned word",
+			text: "Cheap scheduling",
+			want: []string{`error banned: "Cheap" is not allowed: say affordable`},
+		},
+		{
+			name: "required spelling",
+			text: "Star X-Scheduler on github",
+			want: []string{
+				`warning spelling: "github" should be written "GitHub"; fix: replace "github" with "GitHub"`,
+				`info spelling: "X-Scheduler" should be written "x-scheduler"; fix: replace "X-Scheduler" with "x-scheduler"`,
+			},
+		},
+		{
+			name: "too many hashtags and mentions",
+			text: "#one #two #three thanks @a @b https://example.com/#four",
+			want: []string{
+				"warning hashtags: 3 hashtags (maximum 2); fix: remove #three",
+				"info mentions: 2 mentions (maximum 1); fix: remove @b",
+			},
+		},
+		{
+			name: "trailing whitespace",
+			text: "First line \nSecond line",
+			want: []string{"warning trailing_whitespace: line 1 ends with whitespace; fix: remove it"},
+		},
+		{
+			name: "all caps",
+			text: "HUGE news, the FREE tier is OK at https://example.com/FREE #SALE",
+			want: []string{
+				`warning all_caps: "HUGE" is written in capitals; fix: write "Huge"`,
+				`warning all_caps: "FREE" is written in capitals; fix: write "Free"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, problem := range lint.Problems(tt.text) {
+				got = append(got, string(problem.Severity)+" "+problem.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Problems() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLint_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		lint    Lint
+		wantErr string
+	}{
+		{name: "valid", lint: Lint{Rules: []LintRule{{Pattern: "spam"}}, Hashtags: &LintCount{Max: 2}}},
+		{name: "invalid pattern", lint: Lint{Rules: []LintRule{{Pattern: "(spam"}}},
+			wantErr: "lint: rule 1: invalid pattern: error parsing regexp: missing closing ): `(spam`"},
+		{name: "unknown severity", lint: Lint{Rules: []LintRule{{Pattern: "spam", Severity: "fatal"}}},
+			wantErr: `lint: rule 1: unknown severity "fatal" (want error, warning or info)`},
+		{name: "negative cap", lint: Lint{Mentions: &LintCount{Max: -1}}, wantErr: "lint: mentions: max must not be negative"},
+		{name: "unknown whitespace severity", lint: Lint{TrailingWhitespace: "yes"},
+			wantErr: `lint: trailing_whitespace: unknown severity "yes" (want error, warning or info)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.lint.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck_Lint(t *testing.T) {
+	at := time.Now().Add(3 * time.Hour)
+	cfg := &Config{
+		Lint: &Lint{Rules: []LintRule{{Pattern: `(?i)\bcheap\b`, Severity: SeverityError}}, Hashtags: &LintCount{Max: 1}},
+		Posts: []Post{
+			{ID: "sale", Content: "Cheap seats! #sale #deal", ScheduledAt: time.Now().Add(time.Hour)},
+			{ID: "fine", Content: "Tickets on sale", ScheduledAt: time.Now().Add(2 * time.Hour)},
+			{Content: "Cheap parking", ScheduledAt: at},
+		},
+	}
+	unnamed := cfg.Posts[2].Identifier()
+
+	var got []string
+	for _, f := range cfg.Check() {
+		got = append(got, string(f.Severity)+": "+f.Message)
+	}
+	want := []string{
+		`error: post sale: banned: "Cheap" is not allowed`,
+		"warning: post sale: hashtags: 2 hashtags (maximum 1); fix: remove #deal",
+		`error: post ` + unnamed + `: banned: "Cheap" is not allowed`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Check() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// Lint errors hold back their posts but leave the config usable
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+}
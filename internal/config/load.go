@@ -0,0 +1,302 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads and parses the configuration from a YAML file, a directory of
+// YAML files or a glob pattern, following include directives
+//
+// Posts, blackouts, calendar posts, queues and vars from all files are
+// merged; each of the other global settings may be set in only one file. Relative paths are
+// resolved against the file that contains them.
+func Load(path string) (*Config, error) {
+	files, err := expandConfigPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loader{
+		config:  &Config{sources: make(map[string]Source)},
+		visited: make(map[string]bool),
+		now:     time.Now(),
+	}
+	for _, file := range files {
+		if err := l.load(file); err != nil {
+			return nil, err
+		}
+	}
+	if len(l.errs) > 0 {
+		return nil, l.errs
+	}
+	// Account languages may be set in another file than the posts
+	l.config.localize()
+
+	return l.config, nil
+}
+
+// Returns the config files named by a file path, directory or glob pattern
+func expandConfigPath(path string) ([]string, error) {
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config pattern %s: %w", path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no config files match %s", path)
+		}
+		return matches, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	// Directories are searched recursively for YAML files in lexical order
+	var files []string
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && file != path && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if ext := filepath.Ext(file); !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no YAML files in config directory %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Merges config files into a single configuration
+type loader struct {
+	config  *Config
+	visited map[string]bool // Absolute paths of loaded files
+	errs    ValidationErrors
+	now     time.Time
+}
+
+// Loads a single file, merges it and follows its includes
+func (l *loader) load(filename string) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	// Files reached more than once, e.g. via a directory and an include, load once
+	if l.visited[abs] {
+		return nil
+	}
+	l.visited[abs] = true
+
+	part, err := loadFile(filename, l.now)
+	var problems ValidationErrors
+	if errors.As(err, &problems) {
+		// Keep going so that problems in all files are reported together
+		l.errs = append(l.errs, problems...)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	l.merge(part)
+
+	for _, include := range part.Include {
+		files, err := expandConfigPath(part.ResolvePath(include))
+		if err != nil {
+			l.errs.add(part.sourceOf("include"), "include %s: %v", include, err)
+			continue
+		}
+		for _, file := range files {
+			if err := l.load(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Reads and parses a single config file, recording the position of every entry
+func loadFile(filename string, now time.Time) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlErrors(filename, err)
+	}
+
+	config := &Config{dir: filepath.Dir(filename)}
+	// Empty files are valid and contribute nothing
+	if len(doc.Content) == 0 {
+		return config, nil
+	}
+	root := doc.Content[0]
+
+	var errs ValidationErrors
+	checkKnownFields(root, reflect.TypeOf(config), filename, &errs)
+	if err := root.Decode(config); err != nil {
+		errs = append(errs, yamlErrors(filename, err)...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	recordSources(config, root, filename)
+	if errs := config.loadContent(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := config.loadCalendars(now); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return config, nil
+}
+
+// Stores the positions of global settings, posts and their fields
+func recordSources(config *Config, root *yaml.Node, filename string) {
+	at := func(node *yaml.Node) Source {
+		return Source{File: filename, Line: node.Line, Column: node.Column}
+	}
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+
+	config.sources = make(map[string]Source)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		config.sources[root.Content[i].Value] = at(root.Content[i+1])
+	}
+
+	items := func(key string) []*yaml.Node {
+		if node := mappingValue(root, key); node != nil && node.Kind == yaml.SequenceNode {
+			return node.Content
+		}
+		return nil
+	}
+	for i, item := range items("posts") {
+		if i >= len(config.Posts) {
+			break
+		}
+		post := &config.Posts[i]
+		post.Source = at(item)
+		if item.Kind == yaml.MappingNode {
+			post.fields = make(map[string]Source)
+			for j := 0; j+1 < len(item.Content); j += 2 {
+				post.fields[item.Content[j].Value] = at(item.Content[j+1])
+			}
+		}
+	}
+	for i, item := range items("blackouts") {
+		if i < len(config.Blackouts) {
+			config.Blackouts[i].source = at(item)
+		}
+	}
+	for i, item := range items("calendar_posts") {
+		if i < len(config.CalendarPosts) {
+			config.CalendarPosts[i].source = at(item)
+		}
+	}
+	for i, item := range items("queues") {
+		if i < len(config.Queues) {
+			config.Queues[i].source = at(item)
+		}
+	}
+}
+
+// Returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Adds the contents of one file to the merged configuration
+func (l *loader) merge(part *Config) {
+	settings := []struct {
+		name string
+		set  bool
+		copy func()
+	}{
+		{"missed", part.Missed != nil, func() { l.config.Missed = part.Missed }},
+		{"jitter", part.Jitter != 0, func() { l.config.Jitter = part.Jitter }},
+		{"spacing", part.Spacing != nil, func() { l.config.Spacing = part.Spacing }},
+		{"limits", part.Limits != nil, func() { l.config.Limits = part.Limits }},
+		{"accounts", part.Accounts != nil, func() { l.config.Accounts = part.Accounts }},
+		{"languages", part.Languages != nil, func() { l.config.Languages = part.Languages }},
+		{"lint", part.Lint != nil, func() { l.config.Lint = part.Lint }},
+		{"utm", part.UTM != nil, func() { l.config.UTM = part.UTM }},
+		{"require_approval", part.RequireApproval, func() { l.config.RequireApproval = true }},
+		{"signatures", part.Signatures != nil, func() { l.config.Signatures = part.Signatures }},
+		{"pause", part.Pause != nil, func() {
+			pause := *part.Pause
+			pause.File = part.ResolvePath(pause.File)
+			l.config.Pause = &pause
+		}},
+	}
+	for _, setting := range settings {
+		if !setting.set {
+			continue
+		}
+		if other, ok := l.config.sources[setting.name]; ok {
+			l.errs.add(part.sourceOf(setting.name), "%s is already set in %s", setting.name, other)
+			continue
+		}
+		l.config.sources[setting.name] = part.sourceOf(setting.name)
+		setting.copy()
+	}
+
+	// Variables merge by name, but each may be defined only once
+	names := make([]string, 0, len(part.Vars))
+	for name := range part.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key := "vars." + name
+		if other, ok := l.config.sources[key]; ok {
+			l.errs.add(part.sourceOf("vars"), "variable %s is already set in %s", name, other)
+			continue
+		}
+		if l.config.Vars == nil {
+			l.config.Vars = make(map[string]string)
+		}
+		l.config.sources[key] = part.sourceOf("vars")
+		l.config.Vars[name] = part.Vars[name]
+	}
+
+	l.config.Blackouts = append(l.config.Blackouts, part.Blackouts...)
+	l.config.CalendarPosts = append(l.config.CalendarPosts, part.CalendarPosts...)
+	l.config.Queues = append(l.config.Queues, part.Queues...)
+	l.config.Posts = append(l.config.Posts, part.Posts...)
+}
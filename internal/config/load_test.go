@@ -0,0 +1,351 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes files relative to dir, creating parent directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.TrimLeft(content, "\n")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoad_MultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.yaml": `
+jitter: 5m
+vars:
+  event: GopherCon
+pause:
+  file: pause
+include:
+  - posts/*.yaml
+  - extra
+posts:
+  - id: main
+    content: From main
+    scheduled_at: 2030-01-01T09:00:00Z
+`,
+		"posts/a.yaml": `
+posts:
+  - id: a1
+    content: From a
+    scheduled_at: 2030-01-02T09:00:00Z
+  - id: a2
+    content: Also from a
+    scheduled_at: 2030-01-03T09:00:00Z
+`,
+		"posts/b.yaml": `
+include:
+  - ../main.yaml
+vars:
+  city: Berlin
+blackouts:
+  - name: holidays
+    dates: ["2030-01-01"]
+`,
+		"extra/nested/c.yml": `
+posts:
+  - id: c1
+    content: From c
+    scheduled_at: 2030-01-04T09:00:00Z
+`,
+		"extra/.hidden/d.yaml": `
+posts:
+  - id: hidden
+    content: Ignored
+    scheduled_at: 2030-01-05T09:00:00Z
+`,
+	})
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			name: "file with includes",
+			path: filepath.Join(dir, "main.yaml"),
+			want: []string{"main", "a1", "a2", "c1"},
+		},
+		{
+			name: "directory",
+			path: filepath.Join(dir, "posts"),
+			want: []string{"a1", "a2", "main", "c1"},
+		},
+		{
+			name: "glob",
+			path: filepath.Join(dir, "extra", "*", "*.yml"),
+			want: []string{"c1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(tt.path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			var ids []string
+			for _, post := range cfg.Posts {
+				ids = append(ids, post.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Load() posts = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	cfg, err := Load(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := filepath.Join(dir, "posts", "a.yaml") + ":5:5"; cfg.Posts[2].Source.String() != want {
+		t.Errorf("post a2 source = %s, want %s", cfg.Posts[2].Source, want)
+	}
+	if want := filepath.Join(dir, "pause"); cfg.PauseFile() != want {
+		t.Errorf("PauseFile() = %s, want %s", cfg.PauseFile(), want)
+	}
+	if cfg.Vars["event"] != "GopherCon" || cfg.Vars["city"] != "Berlin" {
+		t.Errorf("Load() vars = %v, want event and city", cfg.Vars)
+	}
+	if len(cfg.Blackouts) != 1 {
+		t.Errorf("Load() blackouts = %d, want 1", len(cfg.Blackouts))
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		path    string
+		wantErr string
+	}{
+		{
+			name: "global setting in two files",
+			files: map[string]string{
+				"a.yaml": "jitter: 5m\nposts: []\n",
+				"b.yaml": "jitter: 10m\nposts: []\n",
+			},
+			path:    ".",
+			wantErr: "b.yaml:1:9: jitter is already set in",
+		},
+		{
+			name: "variable in two files",
+			files: map[string]string{
+				"a.yaml": "vars:\n  event: GopherCon\n  city: Berlin\nposts: []\n",
+				"b.yaml": "vars:\n  event: GoLab\nposts: []\n",
+			},
+			path:    ".",
+			wantErr: "b.yaml:2:3: variable event is already set in",
+		},
+		{
+			name:    "include without matches",
+			files:   map[string]string{"a.yaml": "include: [missing/*.yaml]\nposts: []\n"},
+			path:    "a.yaml",
+			wantErr: "a.yaml:1:10: include missing/*.yaml: no config files match",
+		},
+		{
+			name:    "glob without matches",
+			files:   map[string]string{"a.yaml": "posts: []\n"},
+			path:    "*.yml",
+			wantErr: "no config files match",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			_, err := Load(filepath.Join(dir, tt.path))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_DuplicateIDAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": `
+posts:
+  - id: launch
+    content: First
+    scheduled_at: 2030-01-01T09:00:00Z
+`,
+		"b.yaml": `
+posts:
+  - id: other
+    content: Other
+    scheduled_at: 2030-01-01T10:00:00Z
+  - id: launch
+    content: Second
+    scheduled_at: 2030-01-02T09:00:00Z
+`,
+	})
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	err = cfg.Validate()
+	want := filepath.Join(dir, "b.yaml") + ":5:9: post launch: duplicate id (also used by post 0 at " +
+		filepath.Join(dir, "a.yaml") + ":2:5)"
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate() error = %v, want %q", err, want)
+	}
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+spacing:
+  min_gap: 5m
+  polcy: shift
+posts:
+  - content: Typo in the time field
+    schedule_at: 2030-01-01T09:00:00Z
+    enable: true
+  - content: Wrong type
+    scheduled_at: 2030-01-01T10:00:00Z
+    enabled: sometimes
+    colour: red
+`,
+	})
+
+	_, err := Load(filepath.Join(dir, "config.yaml"))
+	if err == nil {
+		t.Fatal("Load() expected error but got nil")
+	}
+	file := filepath.Join(dir, "config.yaml")
+	want := []string{
+		"5 errors:",
+		"  " + file + `:3:3: unknown field "polcy" (did you mean "policy"?)`,
+		"  " + file + `:6:5: unknown field "schedule_at" (did you mean "scheduled_at"?)`,
+		"  " + file + `:7:5: unknown field "enable" (did you mean "enabled"?)`,
+		"  " + file + `:11:5: unknown field "colour"`,
+	}
+	got := strings.Split(err.Error(), "\n")
+	if len(got) != len(want)+1 || strings.Join(got[:len(want)], "\n") != strings.Join(want, "\n") {
+		t.Errorf("Load() error =\n%v\nwant unknown fields followed by a type error:\n%s", err, strings.Join(want, "\n"))
+	}
+	if last := got[len(got)-1]; !strings.HasPrefix(last, "  "+file+":10: cannot unmarshal") {
+		t.Errorf("Load() last error = %q, want type error on line 10", last)
+	}
+}
+
+func TestValidate_CollectsPositionedErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+jitter: -5m
+posts:
+  - id: first
+    scheduled_at: 2030-01-01T09:00:00Z
+  - id: second
+    content: No time
+  - id: third
+    content: Negative jitter
+    scheduled_at: 2030-01-01T11:00:00Z
+    jitter: -1m
+`,
+	})
+
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	err = cfg.Validate()
+	file := filepath.Join(dir, "config.yaml")
+	want := strings.Join([]string{
+		"4 errors:",
+		"  " + file + ":1:9: jitter must not be negative",
+		"  " + file + ":3:5: post first: content is required",
+		"  " + file + ":5:5: post second: scheduled_at is required",
+		"  " + file + ":10:13: post third: jitter must not be negative",
+	}, "\n")
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate() error =\n%v\nwant\n%s", err, want)
+	}
+}
+
+func TestLoad_ContentFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"posts/launch.yaml": `
+posts:
+  - id: plain
+    content_file: copy/plain.txt
+    scheduled_at: 2030-01-01T09:00:00Z
+  - id: markdown
+    content_file: copy/launch.md
+    markdown: true
+    scheduled_at: 2030-01-01T10:00:00Z
+`,
+		"posts/copy/plain.txt": "Line one\nLine two\n\n",
+		"posts/copy/launch.md": "# Launch day\n\nRead **all** about it\nin [the post](https://example.com/launch).\n",
+	})
+
+	cfg, err := Load(filepath.Join(dir, "posts"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := "Line one\nLine two"; cfg.Posts[0].Content != want {
+		t.Errorf("plain content = %q, want %q", cfg.Posts[0].Content, want)
+	}
+	if want := "Launch day\n\nRead all about it in the post (https://example.com/launch)."; cfg.Posts[1].Content != want {
+		t.Errorf("markdown content = %q, want %q", cfg.Posts[1].Content, want)
+	}
+
+	file := filepath.Join(dir, "posts", "launch.yaml")
+	var infos []string
+	for _, finding := range cfg.Check() {
+		if finding.Severity == SeverityInfo {
+			infos = append(infos, finding.Error())
+		}
+	}
+	want := []string{
+		file + ":3:19: post plain: copy/plain.txt is 17 of 280 characters",
+		file + ":6:19: post markdown: copy/launch.md is 68 of 280 characters",
+	}
+	if strings.Join(infos, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Check() infos =\n%s\nwant\n%s", strings.Join(infos, "\n"), strings.Join(want, "\n"))
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"broken.yaml": `
+posts:
+  - id: missing
+    content_file: copy/missing.txt
+    scheduled_at: 2030-01-01T09:00:00Z
+  - id: both
+    content: Inline
+    content_file: posts/copy/plain.txt
+    scheduled_at: 2030-01-01T10:00:00Z
+`,
+	})
+	_, err = Load(filepath.Join(dir, "broken.yaml"))
+	broken := filepath.Join(dir, "broken.yaml")
+	for _, want := range []string{
+		broken + ":3:19: post missing: failed to read content_file: open " + filepath.Join(dir, "copy", "missing.txt"),
+		broken + ":7:19: post both: content and content_file are mutually exclusive",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want containing %q", err, want)
+		}
+	}
+}
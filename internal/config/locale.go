@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Represents settings of a posting account
+type Account struct {
+	Lang string `yaml:"lang,omitempty"` // Language tag of the account's posts, e.g. "ja"
+}
+
+// Parses the post, accepting a mapping of language tag to text as content
+func (p *Post) UnmarshalYAML(value *yaml.Node) error {
+	type plain Post
+
+	node := value
+	var localized map[string]string
+	if text := mappingValue(value, "content"); text != nil {
+		if text.Kind == yaml.AliasNode {
+			text = text.Alias
+		}
+		if text.Kind == yaml.MappingNode {
+			if err := text.Decode(&localized); err != nil {
+				return err
+			}
+			// The rest of the post decodes without the mapping, which doesn't fit the content string
+			rest := *value
+			rest.Content = nil
+			for i := 0; i+1 < len(value.Content); i += 2 {
+				if value.Content[i].Value != "content" {
+					rest.Content = append(rest.Content, value.Content[i], value.Content[i+1])
+				}
+			}
+			node = &rest
+		}
+	}
+
+	if err := node.Decode((*plain)(p)); err != nil {
+		return err
+	}
+	p.Localized = localized
+	return nil
+}
+
+// Returns the language tags of a localized post, sorted
+func (p Post) Languages() []string {
+	langs := make([]string, 0, len(p.Localized))
+	for lang := range p.Localized {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Returns the post with its content replaced by the text in the given language
+func (p Post) WithLanguage(lang string) Post {
+	p.Content = p.Localized[lang]
+	p.Lang = lang
+	return p
+}
+
+// Returns the language the post is published in: its own, or its account's default
+func (c *Config) LanguageFor(post Post) string {
+	if post.Lang != "" {
+		return post.Lang
+	}
+	return c.Accounts[AccountName(post.Account)].Lang
+}
+
+// Picks the text of localized posts in the language of their account
+func (c *Config) localize() {
+	for i := range c.Posts {
+		post := &c.Posts[i]
+		if len(post.Localized) > 0 {
+			*post = post.WithLanguage(c.LanguageFor(*post))
+		}
+	}
+}
+
+// Checks the languages of a localized post for errors
+func (c *Config) validateLanguages(post Post) error {
+	if post.ContentFile != "" {
+		return fmt.Errorf("content map and content_file are mutually exclusive")
+	}
+	if len(post.Variants) > 0 {
+		return fmt.Errorf("content map and variants are mutually exclusive")
+	}
+
+	lang := c.LanguageFor(post)
+	if lang == "" {
+		account := AccountName(post.Account)
+		return fmt.Errorf("no language for account %s (set lang, or accounts.%s.lang)", account, account)
+	}
+	for _, required := range append([]string{lang}, c.Languages...) {
+		if post.Localized[required] == "" {
+			return fmt.Errorf("content is missing language %s", required)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_LocalizedContent(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"accounts.yaml": `
+accounts:
+  acme_jp:
+    lang: ja
+  acme_en:
+    lang: en
+languages: [en, ja]
+`,
+		"posts.yaml": `
+posts:
+  - id: launch-ja
+    account: acme_jp
+    content: &launch
+      en: We are live!
+      ja: 公開しました!
+    scheduled_at: 2099-06-01T09:00:00Z
+    enabled: true
+  - id: launch-en
+    account: acme_en
+    content: *launch
+    scheduled_at: 2099-06-01T18:00:00Z
+    enabled: true
+  - id: launch-fr
+    account: acme_en
+    lang: fr
+    content: *launch
+    scheduled_at: 2099-06-02T09:00:00Z
+    enabled: true
+`,
+	})
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Posts[0].Content; got != "公開しました!" {
+		t.Errorf("launch-ja content = %q", got)
+	}
+	if got := cfg.Posts[1].Content; got != "We are live!" || cfg.Posts[1].Lang != "en" {
+		t.Errorf("launch-en content = %q (%s)", got, cfg.Posts[1].Lang)
+	}
+
+	err = cfg.Validate()
+	want := "posts.yaml:17:14: post launch-fr: content is missing language fr"
+	if err == nil || !strings.HasSuffix(err.Error(), want) || strings.Count(err.Error(), "\n") != 0 {
+		t.Errorf("Validate() error = %v, want %s", err, want)
+	}
+}
+
+func TestConfig_ValidateLanguages(t *testing.T) {
+	localized := map[string]string{"en": "Hello", "ja": "こんにちは"}
+
+	tests := []struct {
+		name      string
+		accounts  map[string]Account
+		languages []string
+		post      Post
+		wantErr   string
+	}{
+		{
+			name:     "account default",
+			accounts: map[string]Account{"default": {Lang: "ja"}},
+			post:     Post{Localized: localized},
+		},
+		{
+			name: "post language",
+			post: Post{Account: "acme", Lang: "en", Localized: localized},
+		},
+		{
+			name:    "no language",
+			post:    Post{Account: "acme", Localized: localized},
+			wantErr: "no language for account acme (set lang, or accounts.acme.lang)",
+		},
+		{
+			name:      "missing required language",
+			languages: []string{"en", "ja", "ko"},
+			post:      Post{Lang: "en", Localized: localized},
+			wantErr:   "content is missing language ko",
+		},
+		{
+			name:    "variants",
+			post:    Post{Lang: "en", Localized: localized, Variants: []Variant{{Content: "Hi"}}},
+			wantErr: "content map and variants are mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Accounts: tt.accounts, Languages: tt.languages}
+			err := cfg.validateLanguages(tt.post)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateLanguages() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("validateLanguages() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck_LocalizedLength(t *testing.T) {
+	cfg := &Config{Posts: []Post{{
+		ID:          "launch",
+		Lang:        "en",
+		Localized:   map[string]string{"en": "Short", "ja": strings.Repeat("長", 141)},
+		ScheduledAt: time.Now().Add(time.Hour),
+	}}}
+
+	err := cfg.Validate()
+	if want := "post launch (ja): content is 282 characters long (maximum 280)"; err == nil || err.Error() != want {
+		t.Errorf("Validate() error = %v, want %s", err, want)
+	}
+}
+
+func TestLoad_LocalizedUnknownField(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.yaml": `
+posts:
+  - content:
+      en: Hello
+    scheduled_at: 2099-06-01T09:00:00Z
+    enabeld: true
+`})
+
+	_, err := Load(filepath.Join(dir, "config.yaml"))
+	if err == nil || !strings.Contains(err.Error(), `unknown field "enabeld" (did you mean "enabled"?)`) {
+		t.Errorf("Load() error = %v, want unknown field", err)
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Represents what to do with a post whose scheduled time has passed
+type MissedAction string
+
+const (
+	MissedSkip         MissedAction = "skip"           // Never post (default)
+	MissedPostNow      MissedAction = "post_now"       // Post immediately if missed earlier today
+	MissedPostIfWithin MissedAction = "post_if_within" // Post immediately if missed by at most Within
+)
+
+// Represents the catch-up policy for missed posts
+//
+// In YAML it is either a scalar (`skip`, `post_now`) or a mapping
+// (`post_if_within: 2h`).
+type MissedPolicy struct {
+	Action MissedAction
+	Within time.Duration
+}
+
+// Parses the scalar or mapping form of the policy
+func (m *MissedPolicy) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		var action string
+		if err := value.Decode(&action); err != nil {
+			return err
+		}
+		switch MissedAction(action) {
+		case MissedSkip, MissedPostNow:
+			*m = MissedPolicy{Action: MissedAction(action)}
+			return nil
+		case MissedPostIfWithin:
+			return fmt.Errorf("line %d: missed policy post_if_within requires a duration, e.g. 'post_if_within: 2h'", value.Line)
+		}
+		return fmt.Errorf("line %d: unknown missed policy %q (want skip, post_now or post_if_within)", value.Line, action)
+	case yaml.MappingNode:
+		var v struct {
+			PostIfWithin time.Duration `yaml:"post_if_within"`
+		}
+		if err := value.Decode(&v); err != nil {
+			return err
+		}
+		*m = MissedPolicy{Action: MissedPostIfWithin, Within: v.PostIfWithin}
+		return nil
+	}
+	return fmt.Errorf("line %d: missed policy must be a string or a mapping", value.Line)
+}
+
+// Encodes the policy in the same form it is parsed from
+func (m MissedPolicy) MarshalYAML() (interface{}, error) {
+	if m.Action == MissedPostIfWithin {
+		return map[string]string{"post_if_within": m.Within.String()}, nil
+	}
+	return string(m.Action), nil
+}
+
+// Checks the policy for errors
+func (m MissedPolicy) Validate() error {
+	switch m.Action {
+	case MissedSkip, MissedPostNow:
+		return nil
+	case MissedPostIfWithin:
+		if m.Within <= 0 {
+			return fmt.Errorf("missed policy post_if_within requires a positive duration")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown missed policy %q", m.Action)
+}
+
+// Returns a human readable description of the policy
+func (m MissedPolicy) String() string {
+	if m.Action == MissedPostIfWithin {
+		return fmt.Sprintf("%s %v", m.Action, m.Within)
+	}
+	return string(m.Action)
+}
+
+// Returns the missed policy that applies to the post
+func (c *Config) MissedPolicyFor(post Post) MissedPolicy {
+	if post.Missed != nil {
+		return *post.Missed
+	}
+	if c.Missed != nil {
+		return *c.Missed
+	}
+	return MissedPolicy{Action: MissedSkip}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMissedPolicy_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    MissedPolicy
+		wantErr bool
+	}{
+		{
+			name:  "skip",
+			input: "missed: skip",
+			want:  MissedPolicy{Action: MissedSkip},
+		},
+		{
+			name:  "post_now",
+			input: "missed: post_now",
+			want:  MissedPolicy{Action: MissedPostNow},
+		},
+		{
+			name:  "post_if_within",
+			input: "missed:\n  post_if_within: 2h",
+			want:  MissedPolicy{Action: MissedPostIfWithin, Within: 2 * time.Hour},
+		},
+		{
+			name:    "post_if_within without duration",
+			input:   "missed: post_if_within",
+			wantErr: true,
+		},
+		{
+			name:    "unknown policy",
+			input:   "missed: retry",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v struct {
+				Missed MissedPolicy `yaml:"missed"`
+			}
+			err := yaml.Unmarshal([]byte(tt.input), &v)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Unmarshal() expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() unexpected error = %v", err)
+			}
+			if v.Missed != tt.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", v.Missed, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_MissedPolicyFor(t *testing.T) {
+	global := MissedPolicy{Action: MissedPostNow}
+	override := MissedPolicy{Action: MissedPostIfWithin, Within: time.Hour}
+
+	tests := []struct {
+		name   string
+		config Config
+		post   Post
+		want   MissedPolicy
+	}{
+		{
+			name: "defaults to skip",
+			want: MissedPolicy{Action: MissedSkip},
+		},
+		{
+			name:   "global policy applies",
+			config: Config{Missed: &global},
+			want:   global,
+		},
+		{
+			name:   "post policy overrides global",
+			config: Config{Missed: &global},
+			post:   Post{Missed: &override},
+			want:   override,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.MissedPolicyFor(tt.post); got != tt.want {
+				t.Errorf("MissedPolicyFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
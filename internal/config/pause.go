@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Represents what happens to posts that come due while paused
+type PauseMode string
+
+const (
+	PauseHold PauseMode = "hold" // Wait until resumed, then post
+	PauseDrop PauseMode = "drop" // Skip the post
+)
+
+// Represents the emergency pause switch of a running scheduler
+type Pause struct {
+	File string    `yaml:"file,omitempty"` // Sentinel file that pauses posting while it exists
+	Mode PauseMode `yaml:"mode,omitempty"` // What happens to posts that come due while paused
+}
+
+// Returns the configured mode, defaulting to hold
+func (p *Pause) ModeOrDefault() PauseMode {
+	if p == nil || p.Mode == "" {
+		return PauseHold
+	}
+	return p.Mode
+}
+
+// Checks the pause settings for errors
+func (p *Pause) Validate() error {
+	switch p.ModeOrDefault() {
+	case PauseHold, PauseDrop:
+		return nil
+	}
+	return fmt.Errorf("pause: unknown mode %q (want hold or drop)", p.Mode)
+}
+
+// Returns the sentinel file path resolved against the config file, if any
+func (c *Config) PauseFile() string {
+	if c.Pause == nil || c.Pause.File == "" {
+		return ""
+	}
+	return c.ResolvePath(c.Pause.File)
+}
+
+// Returns the path resolved against the directory of the config file
+func (c *Config) ResolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.dir, path)
+}
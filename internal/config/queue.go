@@ -0,0 +1,200 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Represents the order in which queue items are posted
+type QueueOrder string
+
+const (
+	QueueOrdered  QueueOrder = "ordered"  // In configuration order (default)
+	QueueShuffled QueueOrder = "shuffled" // In a random order that stays the same between runs
+)
+
+// Represents what happens when every item of a queue was posted
+type QueueEmpty string
+
+const (
+	QueueWarn    QueueEmpty = "warn"    // Skip slots and warn (default)
+	QueueRecycle QueueEmpty = "recycle" // Post items again once their cooldown has passed
+)
+
+// Represents evergreen posts without fixed dates that fill recurring time slots
+//
+// Each slot takes the next item that was not posted yet. Progress is kept in
+// the run state under the queue name.
+type Queue struct {
+	Name      string        `yaml:"name"`                 // Unique name, keys the progress in the run state
+	Account   string        `yaml:"account,omitempty"`    // xurl username to post as (default account if empty)
+	Slots     []QueueSlot   `yaml:"slots"`                // Recurring times at which an item is posted
+	Order     QueueOrder    `yaml:"order,omitempty"`      // Order in which items are posted
+	WhenEmpty QueueEmpty    `yaml:"when_empty,omitempty"` // What happens once every item was posted
+	Cooldown  time.Duration `yaml:"cooldown,omitempty"`   // Minimum time before a recycled item is posted again
+	Enabled   bool          `yaml:"enabled"`              // Only enabled queues fill their slots
+	Items     []QueueItem   `yaml:"items"`                // Posts to publish, one per slot
+
+	Approval `yaml:",inline"`
+
+	source Source
+}
+
+// Represents a recurring posting time of a queue
+type QueueSlot struct {
+	At       string   `yaml:"at"`                 // Wall clock time, e.g. "10:00"
+	Weekdays []string `yaml:"weekdays,omitempty"` // Days the slot applies to (default: every day)
+}
+
+// Represents a post of a queue
+type QueueItem struct {
+	ID          string `yaml:"id,omitempty"`           // Stable identifier (defaults to a hash of the content)
+	Content     string `yaml:"content,omitempty"`      // Text of the post
+	ContentFile string `yaml:"content_file,omitempty"` // File to read the text of the post from
+	Markdown    bool   `yaml:"markdown,omitempty"`     // Convert Markdown content to plain text
+	Template    bool   `yaml:"template,omitempty"`     // Render content as a Go text/template at post time
+	AutoThread  bool   `yaml:"auto_thread,omitempty"`  // Split content over the length limit into a thread
+	Numbered    bool   `yaml:"numbered,omitempty"`     // Append (1/3) style numbering to thread parts
+}
+
+// Represents the queue item a post was generated from
+type QueueRef struct {
+	Queue string // Queue name
+	Item  string // Item key
+}
+
+// Returns the order of the queue
+func (q Queue) OrderOrDefault() QueueOrder {
+	if q.Order == "" {
+		return QueueOrdered
+	}
+	return q.Order
+}
+
+// Returns what happens when the queue runs dry
+func (q Queue) WhenEmptyOrDefault() QueueEmpty {
+	if q.WhenEmpty == "" {
+		return QueueWarn
+	}
+	return q.WhenEmpty
+}
+
+// Returns the position of the queue in the config files
+func (q Queue) Source() Source {
+	return q.source
+}
+
+// Returns the key the item's progress is recorded under
+func (item QueueItem) Key() string {
+	if item.ID != "" {
+		return item.ID
+	}
+	sum := sha256.Sum256([]byte(item.Content))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Returns the slot times on the day of t, in order
+func (q Queue) SlotsOn(t time.Time) []time.Time {
+	var times []time.Time
+	for _, slot := range q.Slots {
+		minutes, err := parseClock(slot.At)
+		if err != nil || !slot.onWeekday(t.Weekday()) {
+			continue
+		}
+		times = append(times, time.Date(t.Year(), t.Month(), t.Day(), minutes/60, minutes%60, 0, 0, t.Location()))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// Reports whether the slot applies to the given weekday
+func (s QueueSlot) onWeekday(day time.Weekday) bool {
+	if len(s.Weekdays) == 0 {
+		return true
+	}
+	for _, name := range s.Weekdays {
+		if wd, ok := parseWeekday(name); ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the post that publishes the item in the slot at the given time
+func (q Queue) Post(item QueueItem, at time.Time) Post {
+	return Post{
+		// The ID identifies the slot, so that a slot is filled at most once
+		ID:          fmt.Sprintf("%s@%s", q.Name, at.Format("20060102T1504")),
+		Account:     q.Account,
+		Content:     item.Content,
+		ScheduledAt: at,
+		Enabled:     q.Enabled,
+		Template:    item.Template,
+		AutoThread:  item.AutoThread,
+		Numbered:    item.Numbered,
+		Source:      q.source,
+		Queue:       &QueueRef{Queue: q.Name, Item: item.Key()},
+		Approval:    q.Approval,
+	}
+}
+
+// Checks the queue for errors
+func (q Queue) Validate() error {
+	if q.Name == "" {
+		return fmt.Errorf("queue: name is required")
+	}
+	if strings.ContainsAny(q.Name, " \t\r\n") {
+		return fmt.Errorf("queue %s: name must not contain whitespace", q.Name)
+	}
+	if len(q.Slots) == 0 {
+		return fmt.Errorf("queue %s: at least one slot is required", q.Name)
+	}
+	for _, slot := range q.Slots {
+		if _, err := parseClock(slot.At); err != nil {
+			return fmt.Errorf("queue %s: invalid slot: %w", q.Name, err)
+		}
+		for _, day := range slot.Weekdays {
+			if _, ok := parseWeekday(day); !ok {
+				return fmt.Errorf("queue %s: invalid weekday %q (want mon, tue, ... sun)", q.Name, day)
+			}
+		}
+	}
+	switch q.OrderOrDefault() {
+	case QueueOrdered, QueueShuffled:
+	default:
+		return fmt.Errorf("queue %s: unknown order %q (want ordered or shuffled)", q.Name, q.Order)
+	}
+	switch q.WhenEmptyOrDefault() {
+	case QueueWarn, QueueRecycle:
+	default:
+		return fmt.Errorf("queue %s: unknown when_empty %q (want warn or recycle)", q.Name, q.WhenEmpty)
+	}
+	if q.Cooldown < 0 {
+		return fmt.Errorf("queue %s: cooldown must not be negative", q.Name)
+	}
+	if q.Cooldown > 0 && q.WhenEmptyOrDefault() != QueueRecycle {
+		return fmt.Errorf("queue %s: cooldown requires when_empty: recycle", q.Name)
+	}
+	if len(q.Items) == 0 {
+		return fmt.Errorf("queue %s: at least one item is required", q.Name)
+	}
+
+	keys := make(map[string]int)
+	for i, item := range q.Items {
+		if item.Content == "" {
+			return fmt.Errorf("queue %s: item %d: content is required", q.Name, i+1)
+		}
+		if item.Numbered && !item.AutoThread {
+			return fmt.Errorf("queue %s: item %d: numbered requires auto_thread", q.Name, i+1)
+		}
+		if first, ok := keys[item.Key()]; ok {
+			return fmt.Errorf("queue %s: item %d: duplicate of item %d", q.Name, i+1, first+1)
+		}
+		keys[item.Key()] = i
+	}
+	return nil
+}
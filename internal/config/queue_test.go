@@ -0,0 +1,105 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueue_Validate(t *testing.T) {
+	valid := func() Queue {
+		return Queue{
+			Name:  "tips",
+			Slots: []QueueSlot{{At: "10:00", Weekdays: []string{"mon", "fri"}}},
+			Items: []QueueItem{{Content: "Tip one"}, {ID: "two", Content: "Tip two"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(q *Queue)
+		wantErr string
+	}{
+		{name: "valid", modify: func(q *Queue) {}},
+		{name: "missing name", modify: func(q *Queue) { q.Name = "" }, wantErr: "queue: name is required"},
+		{name: "no slots", modify: func(q *Queue) { q.Slots = nil }, wantErr: "queue tips: at least one slot is required"},
+		{name: "invalid time", modify: func(q *Queue) { q.Slots[0].At = "10am" }, wantErr: `queue tips: invalid slot: "10am" is not HH:MM`},
+		{name: "invalid weekday", modify: func(q *Queue) { q.Slots[0].Weekdays = []string{"monday", "fr"} },
+			wantErr: `queue tips: invalid weekday "fr" (want mon, tue, ... sun)`},
+		{name: "unknown order", modify: func(q *Queue) { q.Order = "random" },
+			wantErr: `queue tips: unknown order "random" (want ordered or shuffled)`},
+		{name: "cooldown without recycling", modify: func(q *Queue) { q.Cooldown = time.Hour },
+			wantErr: "queue tips: cooldown requires when_empty: recycle"},
+		{name: "empty item", modify: func(q *Queue) { q.Items[1].Content = "" }, wantErr: "queue tips: item 2: content is required"},
+		{name: "duplicate item", modify: func(q *Queue) { q.Items[1] = q.Items[0] }, wantErr: "queue tips: item 2: duplicate of item 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := valid()
+			tt.modify(&queue)
+			err := queue.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueue_SlotsOn(t *testing.T) {
+	queue := Queue{Slots: []QueueSlot{
+		{At: "18:30"},
+		{At: "10:00", Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}},
+	}}
+
+	monday := time.Date(2024, 6, 10, 8, 0, 0, 0, time.UTC)
+	var got []string
+	for _, at := range queue.SlotsOn(monday) {
+		got = append(got, at.Format(time.RFC3339))
+	}
+	if want := "2024-06-10T10:00:00Z,2024-06-10T18:30:00Z"; strings.Join(got, ",") != want {
+		t.Errorf("SlotsOn(monday) = %v, want %s", got, want)
+	}
+	if got := queue.SlotsOn(monday.AddDate(0, 0, 5)); len(got) != 1 {
+		t.Errorf("SlotsOn(saturday) = %v, want only the daily slot", got)
+	}
+}
+
+func TestLoad_Queues(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+queues:
+  - name: tips
+    slots:
+      - at: "10:00"
+    enabled: true
+    items:
+      - content: Inline tip
+      - content_file: tips/long.md
+        markdown: true
+`,
+		"tips/long.md": "A **bold** tip.\n",
+	})
+
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if got := cfg.Queues[0].Items[1].Content; got != "A bold tip." {
+		t.Errorf("item content = %q, want %q", got, "A bold tip.")
+	}
+	if got := cfg.Queues[0].Source().String(); got != filepath.Join(dir, "config.yaml")+":2:5" {
+		t.Errorf("queue source = %s", got)
+	}
+}
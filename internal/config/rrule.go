@@ -0,0 +1,362 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Represents the supported subset of an RFC 5545 recurrence rule
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonth    []time.Month
+	byMonthDay []int
+}
+
+// Represents a BYDAY entry such as MO, 2SU or -1FR
+type weekdayNum struct {
+	ordinal int // 0 means every such weekday in the period
+	weekday time.Weekday
+}
+
+var icalWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parses an RRULE value; UNTIL is read in loc unless given in UTC
+func parseRRule(value string, loc *time.Location) (*rrule, error) {
+	rule := &rrule{interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		key, val := strings.ToUpper(kv[0]), kv[1]
+
+		switch key {
+		case "FREQ":
+			switch strings.ToUpper(val) {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				rule.freq = strings.ToUpper(val)
+			default:
+				return nil, fmt.Errorf("unsupported RRULE frequency %q", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid RRULE interval %q", val)
+			}
+			rule.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid RRULE count %q", val)
+			}
+			rule.count = n
+		case "UNTIL":
+			until, err := parseUntil(val, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.until = until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				wd, err := parseWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.byDay = append(rule.byDay, wd)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(month)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("invalid RRULE month %q", month)
+				}
+				rule.byMonth = append(rule.byMonth, time.Month(n))
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid RRULE month day %q", day)
+				}
+				rule.byMonthDay = append(rule.byMonthDay, n)
+			}
+		case "WKST":
+			// Weeks always start on Monday
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %s", key)
+		}
+	}
+
+	if rule.freq == "" {
+		return nil, fmt.Errorf("RRULE without FREQ")
+	}
+	return rule, nil
+}
+
+// Parses an UNTIL value, which is a date, a UTC time or a time in loc
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	layout := "20060102T150405"
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		layout, loc = "20060102T150405Z", time.UTC
+	case len(value) == 8:
+		layout = "20060102"
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid RRULE until %q", value)
+	}
+	return t, nil
+}
+
+// Parses a BYDAY entry
+func parseWeekdayNum(value string) (weekdayNum, error) {
+	if len(value) < 2 {
+		return weekdayNum{}, fmt.Errorf("invalid RRULE weekday %q", value)
+	}
+	weekday, ok := icalWeekdays[strings.ToUpper(value[len(value)-2:])]
+	if !ok {
+		return weekdayNum{}, fmt.Errorf("invalid RRULE weekday %q", value)
+	}
+	wd := weekdayNum{weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 {
+			return weekdayNum{}, fmt.Errorf("invalid RRULE weekday %q", value)
+		}
+		wd.ordinal = n
+	}
+	return wd, nil
+}
+
+// Returns the occurrence start times before until, starting with start
+func (r *rrule) expand(start time.Time, wall wallClock, until time.Time, max int) []time.Time {
+	var result []time.Time
+	hour, min, sec := start.Clock()
+
+	for period := 0; len(result) < max; period++ {
+		dates, periodStart := r.periodDates(start, period)
+		if !r.until.IsZero() && periodStart.After(dateOf(r.until).AddDate(0, 0, 1)) {
+			break
+		}
+		if periodStart.After(dateOf(until).AddDate(0, 0, 1)) {
+			break
+		}
+
+		for _, date := range dates {
+			t := wall(date.Year(), date.Month(), date.Day(), hour, min, sec)
+			if t.Before(start) {
+				continue
+			}
+			if !r.until.IsZero() && t.After(r.until) {
+				return result
+			}
+			if !t.Before(until) {
+				return result
+			}
+			result = append(result, t)
+			if r.count > 0 && len(result) >= r.count {
+				return result
+			}
+		}
+
+		// Guard against rules that can never match, e.g. BYMONTHDAY=31 in February only
+		if period > 100*max {
+			break
+		}
+	}
+	return result
+}
+
+// Returns the sorted candidate dates of the nth period and the period's first day
+func (r *rrule) periodDates(start time.Time, n int) ([]time.Time, time.Time) {
+	y, m, d := start.Date()
+	step := n * r.interval
+	var dates []time.Time
+
+	switch r.freq {
+	case "DAILY":
+		day := time.Date(y, m, d+step, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			dates = append(dates, day)
+		}
+		return dates, day
+
+	case "WEEKLY":
+		// Weeks start on Monday
+		first := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		first = first.AddDate(0, 0, -((int(first.Weekday())+6)%7)+7*step)
+		for i := 0; i < 7; i++ {
+			day := first.AddDate(0, 0, i)
+			if len(r.byDay) == 0 {
+				if day.Weekday() != start.Weekday() {
+					continue
+				}
+			} else if !r.matchesWeekday(day) {
+				continue
+			}
+			if r.matchesMonth(day) {
+				dates = append(dates, day)
+			}
+		}
+		return dates, first
+
+	case "MONTHLY":
+		first := time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(first) {
+			dates = r.monthDates(first, d)
+		}
+		return dates, first
+
+	default: // YEARLY
+		first := time.Date(y+step, 1, 1, 0, 0, 0, 0, time.UTC)
+		switch {
+		case len(r.byMonth) > 0:
+			for _, month := range r.byMonth {
+				dates = append(dates, r.monthDates(time.Date(first.Year(), month, 1, 0, 0, 0, 0, time.UTC), d)...)
+			}
+		case len(r.byDay) > 0:
+			dates = r.weekdaysIn(first, first.AddDate(1, 0, 0))
+			dates = r.filterMonthDay(dates)
+		case len(r.byMonthDay) > 0:
+			dates = r.monthDates(time.Date(first.Year(), m, 1, 0, 0, 0, 0, time.UTC), d)
+		default:
+			day := time.Date(first.Year(), m, d, 0, 0, 0, 0, time.UTC)
+			// Skip years without the date, such as February 29
+			if day.Day() == d {
+				dates = append(dates, day)
+			}
+		}
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+		return dates, first
+	}
+}
+
+// Returns the matching dates within the month starting at first
+func (r *rrule) monthDates(first time.Time, startDay int) []time.Time {
+	next := first.AddDate(0, 1, 0)
+	var dates []time.Time
+
+	switch {
+	case len(r.byDay) > 0:
+		dates = r.filterMonthDay(r.weekdaysIn(first, next))
+	case len(r.byMonthDay) > 0:
+		daysInMonth := next.AddDate(0, 0, -1).Day()
+		for _, md := range r.byMonthDay {
+			day := md
+			if md < 0 {
+				day = daysInMonth + md + 1
+			}
+			if day >= 1 && day <= daysInMonth {
+				dates = append(dates, first.AddDate(0, 0, day-1))
+			}
+		}
+	default:
+		day := first.AddDate(0, 0, startDay-1)
+		// Skip months without the day, such as the 31st
+		if day.Month() == first.Month() {
+			dates = append(dates, day)
+		}
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// Returns the BYDAY dates within [from, to), honoring ordinals relative to the range
+func (r *rrule) weekdaysIn(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for _, wd := range r.byDay {
+		var matches []time.Time
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == wd.weekday {
+				matches = append(matches, day)
+			}
+		}
+		switch {
+		case wd.ordinal == 0:
+			dates = append(dates, matches...)
+		case wd.ordinal > 0 && wd.ordinal <= len(matches):
+			dates = append(dates, matches[wd.ordinal-1])
+		case wd.ordinal < 0 && -wd.ordinal <= len(matches):
+			dates = append(dates, matches[len(matches)+wd.ordinal])
+		}
+	}
+	return dates
+}
+
+// Keeps dates matching BYMONTHDAY, if set
+func (r *rrule) filterMonthDay(dates []time.Time) []time.Time {
+	if len(r.byMonthDay) == 0 {
+		return dates
+	}
+	var filtered []time.Time
+	for _, date := range dates {
+		if r.matchesMonthDay(date) {
+			filtered = append(filtered, date)
+		}
+	}
+	return filtered
+}
+
+// Reports whether the date is in one of the BYMONTH months
+func (r *rrule) matchesMonth(date time.Time) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, month := range r.byMonth {
+		if date.Month() == month {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the date is one of the BYMONTHDAY days
+func (r *rrule) matchesMonthDay(date time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.byMonthDay {
+		if md == date.Day() || (md < 0 && daysInMonth+md+1 == date.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the date falls on one of the BYDAY weekdays
+func (r *rrule) matchesWeekday(date time.Time) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, wd := range r.byDay {
+		if date.Weekday() == wd.weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns midnight UTC of the time's calendar date
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/content"
+	"gopkg.in/yaml.v3"
+)
+
+// Prefixes the signed content, so that signatures cannot be reused elsewhere
+const signedContentHeader = "x-scheduler approval v1\n"
+
+// Represents the public keys approval signatures are verified against
+type Signatures struct {
+	Keys    map[string]string `yaml:"keys"`              // Base64 Ed25519 public keys, keyed by approved_by name
+	Require bool              `yaml:"require,omitempty"` // Publish only posts with a valid signature
+}
+
+// Represents what an approval signature covers
+type signedContent struct {
+	Post           string            `json:"post,omitempty"`
+	Queue          string            `json:"queue,omitempty"`
+	Calendar       string            `json:"calendar,omitempty"`
+	Account        string            `json:"account"`
+	ScheduledAt    string            `json:"scheduled_at,omitempty"`
+	Content        string            `json:"content,omitempty"`
+	Localized      map[string]string `json:"localized,omitempty"`
+	Variants       []Variant         `json:"variants,omitempty"`
+	Items          []QueueItem       `json:"items,omitempty"`
+	Events         string            `json:"events,omitempty"`
+	EventContent   CalendarContent   `json:"event_content,omitempty"`
+	At             string            `json:"at,omitempty"`
+	Template       bool              `json:"template,omitempty"`
+	Vars           map[string]string `json:"vars,omitempty"`
+	AutoThread     bool              `json:"auto_thread,omitempty"`
+	Numbered       bool              `json:"numbered,omitempty"`
+	UTM            UTM               `json:"utm"`
+	Test           bool              `json:"test,omitempty"`
+	IgnoreBlackout bool              `json:"ignore_blackout,omitempty"`
+	ApprovedBy     string            `json:"approved_by"`
+	ApprovedAt     string            `json:"approved_at"`
+}
+
+// Returns the canonical form of the post's text, time and approval, as signed
+//
+// Content is taken as loaded, after content files were read and a language
+// was picked, so that editing any text the post may publish changes it.
+// Settings outside the post that shape its text are included as they apply
+// to it: the vars its templates use and its effective UTM parameters.
+func (c *Config) SignedContent(post Post) ([]byte, error) {
+	var templates []string
+	if post.Template {
+		templates = append(templates, post.Content)
+		for _, variant := range post.Variants {
+			templates = append(templates, variant.Content)
+		}
+		for _, text := range post.Localized {
+			templates = append(templates, text)
+		}
+	}
+	vars, err := c.signedVars(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	return signedContent{
+		Post:           post.Identifier(),
+		Account:        post.Account,
+		ScheduledAt:    post.ScheduledAt.UTC().Format(time.RFC3339Nano),
+		Content:        post.Content,
+		Localized:      post.Localized,
+		Variants:       post.Variants,
+		Template:       post.Template,
+		Vars:           vars,
+		AutoThread:     post.AutoThread,
+		Numbered:       post.Numbered,
+		UTM:            c.UTMFor(post),
+		Test:           post.Test,
+		IgnoreBlackout: post.IgnoreBlackout,
+		ApprovedBy:     post.ApprovedBy,
+		ApprovedAt:     post.ApprovedAt.UTC().Format(time.RFC3339Nano),
+	}.encode()
+}
+
+// Returns the canonical form of the queue's items and approval, as signed
+func (c *Config) SignedQueueContent(queue Queue) ([]byte, error) {
+	var templates []string
+	for _, item := range queue.Items {
+		if item.Template {
+			templates = append(templates, item.Content)
+		}
+	}
+	vars, err := c.signedVars(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	return signedContent{
+		Queue:      queue.Name,
+		Account:    queue.Account,
+		Items:      queue.Items,
+		Vars:       vars,
+		UTM:        c.UTMFor(queue.Post(QueueItem{}, time.Time{})),
+		ApprovedBy: queue.ApprovedBy,
+		ApprovedAt: queue.ApprovedAt.UTC().Format(time.RFC3339Nano),
+	}.encode()
+}
+
+// Returns the canonical form of the calendar_posts entry and approval, as
+// signed
+//
+// The signature covers the contents of the .ics file, so that any edit to
+// its events invalidates it.
+func (c *Config) SignedCalendarContent(source CalendarPosts) ([]byte, error) {
+	path := source.path
+	if path == "" {
+		path = c.ResolvePath(source.File)
+	}
+	events, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+
+	return signedContent{
+		Calendar:     source.File,
+		Account:      source.Account,
+		Events:       string(events),
+		EventContent: source.ContentOrDefault(),
+		At:           source.At,
+		UTM:          c.UTMFor(Post{Account: source.Account}),
+		ApprovedBy:   source.ApprovedBy,
+		ApprovedAt:   source.ApprovedAt.UTC().Format(time.RFC3339Nano),
+	}.encode()
+}
+
+// Returns the vars the templates use, or every var if one of them may use any
+func (c *Config) signedVars(templates []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, text := range templates {
+		names, all, err := content.TemplateVars(text)
+		if err != nil {
+			return nil, err
+		}
+		if all {
+			return c.Vars, nil
+		}
+		for _, name := range names {
+			if value, ok := c.Vars[name]; ok {
+				vars[name] = value
+			}
+		}
+	}
+	return vars, nil
+}
+
+// Returns the header followed by the JSON encoding, whose map keys are sorted
+func (s signedContent) encode() ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signed content: %w", err)
+	}
+	return append([]byte(signedContentHeader), data...), nil
+}
+
+// Returns the base64 encoded signature of content
+func Sign(key ed25519.PrivateKey, content []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, content))
+}
+
+// Parses a PEM encoded PKCS #8 Ed25519 private key, as written by
+// openssl genpkey -algorithm ed25519
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an Ed25519 key")
+	}
+	return edKey, nil
+}
+
+// Parses a base64 Ed25519 public key, either the raw 32 bytes or the DER
+// encoding found between the lines of openssl pkey -pubout
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	if len(data) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(data), nil
+	}
+	key, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("not an Ed25519 public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an Ed25519 public key")
+	}
+	return edKey, nil
+}
+
+// Returns the base64 encoding of the raw public key
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// Reads signature settings from a file in the format of the signatures
+// setting, for keeping the trusted keys outside the config files
+func LoadSignatures(path string) (*Signatures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signatures file: %w", err)
+	}
+
+	var signatures Signatures
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&signatures); err != nil {
+		return nil, fmt.Errorf("failed to parse signatures file %s: %w", path, err)
+	}
+	if err := signatures.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &signatures, nil
+}
+
+// Checks the signature settings for errors
+func (s *Signatures) Validate() error {
+	if len(s.Keys) == 0 {
+		return fmt.Errorf("signatures: keys must list at least one approver")
+	}
+	for name, key := range s.Keys {
+		if _, err := ParsePublicKey(key); err != nil {
+			return fmt.Errorf("signatures: key of %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Checks the post's signature against the key of its approver
+//
+// Posts of a queue carry the queue's signature, which covers every item,
+// and posts generated from calendar_posts that of their entry.
+func (c *Config) VerifySignature(post Post) error {
+	if c.Signatures == nil {
+		return fmt.Errorf("no signatures keys configured")
+	}
+	encoded, ok := c.Signatures.Keys[post.ApprovedBy]
+	if !ok {
+		return fmt.Errorf("no key for approver %s", post.ApprovedBy)
+	}
+	key, err := ParsePublicKey(encoded)
+	if err != nil {
+		return fmt.Errorf("key of %s: %w", post.ApprovedBy, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(post.Signature)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed signature")
+	}
+
+	var signed []byte
+	if post.Queue != nil {
+		queue, ok := c.queue(post.Queue.Queue)
+		if !ok {
+			return fmt.Errorf("unknown queue %s", post.Queue.Queue)
+		}
+		signed, err = c.SignedQueueContent(queue)
+	} else if post.Calendar != "" {
+		source, ok := c.calendarPosts(post)
+		if !ok {
+			return fmt.Errorf("unknown calendar_posts entry %s", post.Calendar)
+		}
+		signed, err = c.SignedCalendarContent(source)
+	} else {
+		signed, err = c.SignedContent(post)
+	}
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, signed, signature) {
+		return fmt.Errorf("signature does not match the content approved by %s", post.ApprovedBy)
+	}
+	return nil
+}
+
+// Returns the queue with the given name
+func (c *Config) queue(name string) (Queue, bool) {
+	for _, queue := range c.Queues {
+		if queue.Name == name {
+			return queue, true
+		}
+	}
+	return Queue{}, false
+}
+
+// Returns the calendar_posts entry the post was generated from
+func (c *Config) calendarPosts(post Post) (CalendarPosts, bool) {
+	for _, source := range c.CalendarPosts {
+		if source.File == post.Calendar && source.path == post.Source.File {
+			return source, true
+		}
+	}
+	return CalendarPosts{}, false
+}
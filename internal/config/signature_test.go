@@ -0,0 +1,316 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeys(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil || !parsed.Equal(private) {
+		t.Fatalf("ParsePrivateKey() = %v, %v", parsed, err)
+	}
+	if _, err := ParsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("ParsePrivateKey() expected error for non-PEM input")
+	}
+
+	spki, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, encoded := range map[string]string{
+		"raw": EncodePublicKey(public),
+		"der": base64.StdEncoding.EncodeToString(spki),
+	} {
+		if got, err := ParsePublicKey(encoded); err != nil || !got.Equal(public) {
+			t.Errorf("ParsePublicKey(%s) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParsePublicKey("c2hvcnQ="); err == nil {
+		t.Error("ParsePublicKey() expected error for a short key")
+	}
+}
+
+func TestConfig_VerifySignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	approval := Approval{Status: StatusApproved, ApprovedBy: "alice", ApprovedAt: time.Date(2030, 2, 27, 17, 30, 0, 0, time.UTC)}
+
+	// Returns a config with a signed post and a signed queue
+	signedConfig := func() *Config {
+		cfg := &Config{
+			Signatures: &Signatures{Keys: map[string]string{"alice": EncodePublicKey(public)}},
+			Vars:       map[string]string{"event": "GopherCon", "city": "Berlin"},
+			UTM:        &UTM{Source: "x", Campaign: "launch"},
+			Posts: []Post{{
+				ID:          "launch",
+				Content:     "{{.Vars.event}} is live! https://example.com",
+				Template:    true,
+				ScheduledAt: time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC),
+				Approval:    approval,
+			}},
+			Queues: []Queue{{
+				Name:     "tips",
+				Items:    []QueueItem{{Content: "Tip one"}, {Content: "Tip two"}},
+				Approval: approval,
+			}},
+		}
+		post, queue := &cfg.Posts[0], &cfg.Queues[0]
+		signedPost, err := cfg.SignedContent(*post)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signedQueue, err := cfg.SignedQueueContent(*queue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		post.Signature = Sign(private, signedPost)
+		queue.Signature = Sign(private, signedQueue)
+		return cfg
+	}
+	mismatch := "signature does not match the content approved by alice"
+
+	tests := []struct {
+		name    string
+		edit    func(cfg *Config, post *Post)
+		queue   bool
+		wantErr string
+	}{
+		{name: "valid", edit: func(cfg *Config, post *Post) {}},
+		{name: "unused var edited", edit: func(cfg *Config, post *Post) { cfg.Vars["city"] = "Tokyo" }},
+		{name: "content edited", edit: func(cfg *Config, post *Post) { post.Content += "!" }, wantErr: mismatch},
+		{name: "used var edited", edit: func(cfg *Config, post *Post) { cfg.Vars["event"] = "GoLab" }, wantErr: mismatch},
+		{name: "global utm edited", edit: func(cfg *Config, post *Post) { cfg.UTM.Campaign = "sale" }, wantErr: mismatch},
+		{name: "rescheduled", edit: func(cfg *Config, post *Post) { post.ScheduledAt = post.ScheduledAt.Add(time.Hour) }, wantErr: mismatch},
+		{name: "made a test post", edit: func(cfg *Config, post *Post) { post.Test = true }, wantErr: mismatch},
+		{name: "blackouts ignored", edit: func(cfg *Config, post *Post) { post.IgnoreBlackout = true }, wantErr: mismatch},
+		{name: "approver changed", edit: func(cfg *Config, post *Post) { post.ApprovedBy = "bob" }, wantErr: "no key for approver bob"},
+		{name: "malformed", edit: func(cfg *Config, post *Post) { post.Signature = "c2ln" }, wantErr: "malformed signature"},
+		{name: "queue item", queue: true, edit: func(cfg *Config, post *Post) {}},
+		{
+			name:    "queue item edited",
+			queue:   true,
+			edit:    func(cfg *Config, post *Post) { cfg.Queues[0].Items[1].Content = "Tip 2" },
+			wantErr: mismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := signedConfig()
+			tt.edit(cfg, &cfg.Posts[0])
+			post := cfg.Posts[0]
+			if tt.queue {
+				post = cfg.Queues[0].Post(cfg.Queues[0].Items[0], time.Now())
+			}
+
+			err := cfg.VerifySignature(post)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("VerifySignature() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ApprovalBlockSignatures(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]string{"alice": EncodePublicKey(public)}
+	approved := Post{ID: "launch", Content: "We are live!", Approval: Approval{Status: StatusApproved, ApprovedBy: "alice"}}
+	signedContent, err := (&Config{}).SignedContent(approved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := approved
+	signed.Signature = Sign(private, signedContent)
+	tampered := signed
+	tampered.Content = "We are live!!"
+
+	tests := []struct {
+		name    string
+		require bool
+		post    Post
+		want    string
+	}{
+		{name: "signed", require: true, post: signed, want: ""},
+		{name: "unsigned when required", require: true, post: approved, want: "approval is not signed"},
+		{name: "unsigned", post: approved, want: ""},
+		{name: "no status when required", require: true, post: Post{}, want: "not approved (no status)"},
+		{
+			name: "tampered",
+			post: tampered,
+			want: "approval signature is invalid (signature does not match the content approved by alice)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Signatures: &Signatures{Keys: keys, Require: tt.require}}
+			if got := cfg.ApprovalBlock(tt.post); got != tt.want {
+				t.Errorf("ApprovalBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_CalendarPostsSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("20060102")
+	events := calendar("BEGIN:VEVENT\nUID:launch\nDTSTART;VALUE=DATE:" + tomorrow +
+		"\nRRULE:FREQ=DAILY;COUNT=2\nSUMMARY:Launch\nEND:VEVENT")
+	configYAML := `
+signatures:
+  require: true
+  keys:
+    alice: ` + EncodePublicKey(public) + `
+calendar_posts:
+  - file: campaign.ics
+    enabled: true
+    status: approved
+    approved_by: alice
+`
+	for name, content := range map[string]string{"campaign.ics": events, "config.yaml": configYAML} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Returns why each generated post is held back
+	blocks := func() []string {
+		cfg, err := Load(filepath.Join(dir, "config.yaml"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(cfg.Posts) != 2 {
+			t.Fatalf("Load() generated %d posts, want 2", len(cfg.Posts))
+		}
+		var blocks []string
+		for _, post := range cfg.Posts {
+			blocks = append(blocks, cfg.ApprovalBlock(post))
+		}
+		return blocks
+	}
+
+	// Sign the entry the way approve -key does
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	signed, err := cfg.SignedCalendarContent(cfg.CalendarPosts[0])
+	if err != nil {
+		t.Fatalf("SignedCalendarContent() error = %v", err)
+	}
+	if err := SetFields(cfg.CalendarPosts[0].Source(), []Field{{Key: "signature", Value: Sign(private, signed)}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks() {
+		if block != "" {
+			t.Errorf("ApprovalBlock() = %q for signed calendar post, want none", block)
+		}
+	}
+
+	// Editing an event after approval holds back every generated post
+	edited := strings.Replace(events, "SUMMARY:Launch", "SUMMARY:Launch now", 1)
+	if err := os.WriteFile(filepath.Join(dir, "campaign.ics"), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks() {
+		if !strings.Contains(block, "signature does not match") {
+			t.Errorf("ApprovalBlock() = %q for edited calendar, want signature mismatch", block)
+		}
+	}
+}
+
+func TestLoadSignatures(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: "keys:\n  alice: " + EncodePublicKey(public) + "\nrequire: true\n"},
+		{name: "no keys", content: "require: true\n", wantErr: true},
+		{name: "unknown field", content: "keys:\n  alice: " + EncodePublicKey(public) + "\nrequired: true\n", wantErr: true},
+		{name: "invalid key", content: "keys:\n  alice: c2hvcnQ=\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "signatures.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			signatures, err := LoadSignatures(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("LoadSignatures() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadSignatures() unexpected error = %v", err)
+			}
+			if !signatures.Require || signatures.Keys["alice"] != EncodePublicKey(public) {
+				t.Errorf("LoadSignatures() = %+v, want alice's key and require", signatures)
+			}
+		})
+	}
+}
+
+func TestSignatures_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		signatures Signatures
+		wantErr    string
+	}{
+		{name: "no keys", signatures: Signatures{Require: true}, wantErr: "signatures: keys must list at least one approver"},
+		{
+			name:       "bad key",
+			signatures: Signatures{Keys: map[string]string{"alice": "not base64!"}},
+			wantErr:    "signatures: key of alice: invalid base64: illegal base64 data at input byte 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signatures.Validate()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Represents how spacing conflicts are resolved
+type SpacingPolicy string
+
+const (
+	SpacingFail  SpacingPolicy = "fail"  // Reject conflicting schedules during validation
+	SpacingShift SpacingPolicy = "shift" // Push later posts forward at execution time
+)
+
+// Represents the minimum gap between posts on the same account
+type Spacing struct {
+	MinGap   time.Duration            `yaml:"min_gap"`            // Minimum duration between two posts
+	Policy   SpacingPolicy            `yaml:"policy,omitempty"`   // What to do about conflicting posts
+	Accounts map[string]time.Duration `yaml:"accounts,omitempty"` // Per-account overrides of min_gap
+}
+
+// Returns the minimum gap for the given account
+func (s *Spacing) GapFor(account string) time.Duration {
+	if s == nil {
+		return 0
+	}
+	if gap, ok := s.Accounts[account]; ok {
+		return gap
+	}
+	return s.MinGap
+}
+
+// Returns the configured policy, defaulting to fail
+func (s *Spacing) PolicyOrDefault() SpacingPolicy {
+	if s == nil || s.Policy == "" {
+		return SpacingFail
+	}
+	return s.Policy
+}
+
+// Checks the spacing settings for errors
+func (s *Spacing) Validate() error {
+	switch s.PolicyOrDefault() {
+	case SpacingFail, SpacingShift:
+	default:
+		return fmt.Errorf("spacing: unknown policy %q (want fail or shift)", s.Policy)
+	}
+	if s.MinGap < 0 {
+		return fmt.Errorf("spacing: min_gap must not be negative")
+	}
+	for account, gap := range s.Accounts {
+		if gap < 0 {
+			return fmt.Errorf("spacing: min_gap for account %s must not be negative", AccountName(account))
+		}
+	}
+	return nil
+}
+
+// Represents two posts on the same account scheduled too close together
+type SpacingConflict struct {
+	Account     string
+	First       Post
+	Second      Post
+	Gap         time.Duration
+	MinGap      time.Duration
+	SecondIndex int
+}
+
+// Returns a human readable description of the conflict
+func (sc SpacingConflict) String() string {
+	return fmt.Sprintf("post %s is %v after post %s on account %s (minimum gap %v)",
+		sc.Second.Identifier(), sc.Gap, sc.First.Identifier(), AccountName(sc.Account), sc.MinGap)
+}
+
+// Returns conflicts between enabled future posts closer than the minimum gap
+func (c *Config) SpacingConflicts(now time.Time) []SpacingConflict {
+	if c.Spacing == nil {
+		return nil
+	}
+
+	type indexedPost struct {
+		index int
+		post  Post
+	}
+	byAccount := make(map[string][]indexedPost)
+	for i, post := range c.Posts {
+		// Test posts run immediately and past posts are history
+		if !post.Enabled || post.Test || !post.ScheduledAt.After(now) {
+			continue
+		}
+		byAccount[post.Account] = append(byAccount[post.Account], indexedPost{i, post})
+	}
+
+	accounts := make([]string, 0, len(byAccount))
+	for account := range byAccount {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	var conflicts []SpacingConflict
+	for _, account := range accounts {
+		minGap := c.Spacing.GapFor(account)
+		if minGap <= 0 {
+			continue
+		}
+
+		posts := byAccount[account]
+		sort.SliceStable(posts, func(i, j int) bool {
+			return posts[i].post.ScheduledAt.Before(posts[j].post.ScheduledAt)
+		})
+		for i := 1; i < len(posts); i++ {
+			gap := posts[i].post.ScheduledAt.Sub(posts[i-1].post.ScheduledAt)
+			if gap < minGap {
+				conflicts = append(conflicts, SpacingConflict{
+					Account:     account,
+					First:       posts[i-1].post,
+					Second:      posts[i].post,
+					Gap:         gap,
+					MinGap:      minGap,
+					SecondIndex: posts[i].index,
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// Returns the display name of an account
+func AccountName(account string) string {
+	if account == "" {
+		return "default"
+	}
+	return account
+}
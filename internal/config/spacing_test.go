@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_SpacingConflicts(t *testing.T) {
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	config := Config{
+		Spacing: &Spacing{
+			MinGap:   5 * time.Minute,
+			Accounts: map[string]time.Duration{"quiet": 0},
+		},
+		Posts: []Post{
+			{ID: "a", Content: "A", ScheduledAt: at(9, 0), Enabled: true},
+			{ID: "b", Content: "B", ScheduledAt: at(9, 2), Enabled: true},
+			{ID: "c", Content: "C", ScheduledAt: at(9, 2), Enabled: true, Account: "brand"},
+			{ID: "d", Content: "D", ScheduledAt: at(9, 3), Enabled: false},
+			{ID: "e", Content: "E", ScheduledAt: at(9, 0), Enabled: true, Account: "quiet"},
+			{ID: "f", Content: "F", ScheduledAt: at(9, 0), Enabled: true, Account: "quiet"},
+			{ID: "g", Content: "G", ScheduledAt: at(7, 0), Enabled: true},
+			{ID: "h", Content: "H", ScheduledAt: at(7, 1), Enabled: true},
+		},
+	}
+
+	conflicts := config.SpacingConflicts(now)
+	if len(conflicts) != 1 {
+		t.Fatalf("SpacingConflicts() returned %d conflicts, want 1: %v", len(conflicts), conflicts)
+	}
+
+	conflict := conflicts[0]
+	if conflict.First.ID != "a" || conflict.Second.ID != "b" || conflict.Gap != 2*time.Minute {
+		t.Errorf("SpacingConflicts()[0] = %s, want b 2m after a", conflict)
+	}
+}
+
+func TestConfig_ValidateSpacingPolicy(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	posts := []Post{
+		{ID: "a", Content: "A", ScheduledAt: future, Enabled: true},
+		{ID: "b", Content: "B", ScheduledAt: future.Add(time.Minute), Enabled: true},
+	}
+
+	failConfig := Config{Spacing: &Spacing{MinGap: 5 * time.Minute}, Posts: posts}
+	err := failConfig.Validate()
+	if err == nil || !strings.Contains(err.Error(), "post b: spacing conflict with post a") {
+		t.Errorf("Validate() error = %v, want spacing conflict", err)
+	}
+
+	shiftConfig := Config{Spacing: &Spacing{MinGap: 5 * time.Minute, Policy: SpacingShift}, Posts: posts}
+	if err := shiftConfig.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+
+	badConfig := Config{Spacing: &Spacing{Policy: "later"}, Posts: posts}
+	if err := badConfig.Validate(); err == nil {
+		t.Errorf("Validate() expected error for unknown policy")
+	}
+}
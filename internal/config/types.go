@@ -1,17 +1,108 @@
 package config
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Represents the complete configuration structure
 type Config struct {
-	Posts []Post `yaml:"posts"`
+	Missed    *MissedPolicy      `yaml:"missed,omitempty"`    // Default catch-up policy for missed posts
+	Jitter    time.Duration      `yaml:"jitter,omitempty"`    // Default random shift of execution times
+	Spacing   *Spacing           `yaml:"spacing,omitempty"`   // Minimum gap between posts per account
+	Limits    *Limits            `yaml:"limits,omitempty"`    // Posting caps per account
+	Blackouts []Blackout         `yaml:"blackouts,omitempty"` // Periods in which nothing is posted
+	Pause     *Pause             `yaml:"pause,omitempty"`     // Emergency pause switch
+	Lint      *Lint              `yaml:"lint,omitempty"`      // Style rules post content is checked against
+	UTM       *UTM               `yaml:"utm,omitempty"`       // Campaign parameters added to links
+	Include   []string           `yaml:"include,omitempty"`   // Further config files, directories or globs
+	Vars      map[string]string  `yaml:"vars,omitempty"`      // Variables available to content templates
+	Accounts  map[string]Account `yaml:"accounts,omitempty"`  // Per-account settings, keyed by xurl username ("default" for the default account)
+	Languages []string           `yaml:"languages,omitempty"` // Language tags every localized post must provide
+	Posts     []Post             `yaml:"posts,omitempty"`     // Scheduled posts
+
+	CalendarPosts   []CalendarPosts `yaml:"calendar_posts,omitempty"`   // Posts generated from .ics events
+	Queues          []Queue         `yaml:"queues,omitempty"`           // Evergreen posts filling recurring time slots
+	RequireApproval bool            `yaml:"require_approval,omitempty"` // Publish only posts with status approved
+	Signatures      *Signatures     `yaml:"signatures,omitempty"`       // Public keys approval signatures are verified against
+
+	dir     string            // Directory of the config file, for resolving relative paths
+	sources map[string]Source // Positions of the global settings
 }
 
 // Represents a single scheduled post
 type Post struct {
-	Content     string    `yaml:"content"`
-	ScheduledAt time.Time `yaml:"scheduled_at"`
-	Enabled     bool      `yaml:"enabled"`
-	Test        bool      `yaml:"test,omitempty"`    // Execute immediately for testing
-	DryRun      bool      `yaml:"dry_run,omitempty"` // Don't actually post (test mode only)
+	ID          string    `yaml:"id,omitempty"`           // Stable identifier (defaults to a content+time hash)
+	Account     string    `yaml:"account,omitempty"`      // xurl username to post as (default account if empty)
+	Content     string    `yaml:"content,omitempty"`      // Text of the post, or a mapping of language tag to text
+	Lang        string    `yaml:"lang,omitempty"`         // Language to post in, overriding the account's
+	ContentFile string    `yaml:"content_file,omitempty"` // File to read the text of the post from
+	Markdown    bool      `yaml:"markdown,omitempty"`     // Convert Markdown content to plain text
+	Variants    []Variant `yaml:"variants,omitempty"`     // Alternative texts, one of which is posted
+	Rotation    Rotation  `yaml:"rotation,omitempty"`     // How the variant is chosen
+	AutoThread  bool      `yaml:"auto_thread,omitempty"`  // Split content over the length limit into a thread
+	Numbered    bool      `yaml:"numbered,omitempty"`     // Append (1/3) style numbering to thread parts
+	ScheduledAt time.Time `yaml:"scheduled_at"`           // When to post, in RFC 3339 format
+	Enabled     bool      `yaml:"enabled"`                // Only enabled posts are published
+	Test        bool      `yaml:"test,omitempty"`         // Execute immediately for testing
+	DryRun      bool      `yaml:"dry_run,omitempty"`      // Don't actually post (test mode only)
+
+	Missed *MissedPolicy  `yaml:"missed,omitempty"` // Overrides the global missed policy
+	Jitter *time.Duration `yaml:"jitter,omitempty"` // Overrides the global jitter (0 disables it)
+
+	IgnoreBlackout bool `yaml:"ignore_blackout,omitempty"` // Post even during blackouts
+	Template       bool `yaml:"template,omitempty"`        // Render content as a Go text/template at post time
+	UTM            *UTM `yaml:"utm,omitempty"`             // Overrides the global utm parameters
+
+	Approval `yaml:",inline"`
+
+	Localized map[string]string `yaml:"-"` // Text per language tag, when content is a mapping
+	Source    Source            `yaml:"-"` // Where the post was defined
+	Queue     *QueueRef         `yaml:"-"` // Queue item the post was generated from, if any
+	Calendar  string            `yaml:"-"` // File of the calendar_posts entry the post was generated from, if any
+
+	fields map[string]Source // Positions of the post's fields
+}
+
+// Returns the position of a field of the post, or of the post itself
+func (p Post) sourceOf(field string) Source {
+	if source, ok := p.fields[field]; ok {
+		return source
+	}
+	return p.Source
+}
+
+// Returns the position of a global setting
+func (c *Config) sourceOf(setting string) Source {
+	return c.sources[setting]
+}
+
+// Represents the location of an entry in a config file
+type Source struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// Returns file:line:col, omitting the parts that are unknown
+func (s Source) String() string {
+	switch {
+	case s.Line == 0:
+		return s.File
+	case s.Column == 0:
+		return fmt.Sprintf("%s:%d", s.File, s.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line, s.Column)
+}
+
+// Returns the post ID, falling back to a hash of scheduled time and content
+func (p Post) Identifier() string {
+	if p.ID != "" {
+		return p.ID
+	}
+	sum := sha256.Sum256([]byte(p.ScheduledAt.UTC().Format(time.RFC3339) + "\n" + strings.Join(p.Contents(), "\n")))
+	return hex.EncodeToString(sum[:])[:16]
 }
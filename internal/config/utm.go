@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zinrai/x-scheduler/internal/content"
+)
+
+// Represents campaign tracking parameters added to links at post time
+type UTM struct {
+	Source   string   `yaml:"source,omitempty"`   // utm_source, e.g. "x"
+	Medium   string   `yaml:"medium,omitempty"`   // utm_medium, e.g. "social"
+	Campaign string   `yaml:"campaign,omitempty"` // utm_campaign, e.g. "spring-launch"
+	Term     string   `yaml:"term,omitempty"`     // utm_term
+	Content  string   `yaml:"content,omitempty"`  // utm_content
+	Domains  []string `yaml:"domains,omitempty"`  // Hosts whose links are tagged, including subdomains (default: all)
+}
+
+// Returns the UTM settings of the post: the global ones, overridden field by field
+func (c *Config) UTMFor(post Post) UTM {
+	var utm UTM
+	if c.UTM != nil {
+		utm = *c.UTM
+	}
+	if post.UTM == nil {
+		return utm
+	}
+	for _, field := range []struct{ dst, src *string }{
+		{&utm.Source, &post.UTM.Source},
+		{&utm.Medium, &post.UTM.Medium},
+		{&utm.Campaign, &post.UTM.Campaign},
+		{&utm.Term, &post.UTM.Term},
+		{&utm.Content, &post.UTM.Content},
+	} {
+		if *field.src != "" {
+			*field.dst = *field.src
+		}
+	}
+	if post.UTM.Domains != nil {
+		utm.Domains = post.UTM.Domains
+	}
+	return utm
+}
+
+// Returns text with the parameters added to its links
+func (u UTM) Apply(text string) string {
+	params := []content.Param{
+		{Name: "utm_source", Value: u.Source},
+		{Name: "utm_medium", Value: u.Medium},
+		{Name: "utm_campaign", Value: u.Campaign},
+		{Name: "utm_term", Value: u.Term},
+		{Name: "utm_content", Value: u.Content},
+	}
+	return content.TagURLs(text, params, u.Domains)
+}
+
+// Checks the UTM settings for errors
+func (u *UTM) Validate() error {
+	for _, domain := range u.Domains {
+		if domain == "" || strings.ContainsAny(domain, "/:?# ") {
+			return fmt.Errorf("utm: domain %q must be a host name, e.g. example.com", domain)
+		}
+	}
+	return nil
+}
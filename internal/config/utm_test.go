@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_RenderContentUTM(t *testing.T) {
+	cfg := &Config{
+		UTM:  &UTM{Source: "x", Medium: "social", Campaign: "always-on", Domains: []string{"example.com"}},
+		Vars: map[string]string{"site": "https://example.com"},
+	}
+	at := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		post Post
+		want string
+	}{
+		{
+			name: "global parameters",
+			post: Post{Content: "New post: https://example.com/blog#top, via https://partner.org"},
+			want: "New post: https://example.com/blog?utm_source=x&utm_medium=social&utm_campaign=always-on#top, via https://partner.org",
+		},
+		{
+			name: "post overrides campaign",
+			post: Post{Content: "{{.Vars.site}}/launch", Template: true, UTM: &UTM{Campaign: "launch"}},
+			want: "https://example.com/launch?utm_source=x&utm_medium=social&utm_campaign=launch",
+		},
+		{
+			name: "post overrides domains",
+			post: Post{Content: "https://partner.org", UTM: &UTM{Domains: []string{"partner.org"}}},
+			want: "https://partner.org?utm_source=x&utm_medium=social&utm_campaign=always-on",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.RenderContent(tt.post, at)
+			if err != nil {
+				t.Fatalf("RenderContent() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUTM_Validate(t *testing.T) {
+	if err := (&UTM{Domains: []string{"example.com"}}).Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+	want := `utm: domain "https://example.com" must be a host name, e.g. example.com`
+	if err := (&UTM{Domains: []string{"https://example.com"}}).Validate(); err == nil || err.Error() != want {
+		t.Errorf("Validate() error = %v, want %q", err, want)
+	}
+}
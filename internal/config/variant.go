@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Represents how one of a post's variants is chosen
+type Rotation string
+
+const (
+	RotationRoundRobin  Rotation = "round_robin"  // Cycle through the variants in order (default)
+	RotationRandom      Rotation = "random"       // Pick a random variant, favoring higher weights
+	RotationLeastRecent Rotation = "least_recent" // Pick the variant posted longest ago
+)
+
+// Represents an alternative text of a post
+//
+// In YAML it is either the text itself or a mapping with content and weight.
+type Variant struct {
+	Content string
+	Weight  int // Relative frequency under round_robin and random (default 1)
+}
+
+// Parses the scalar or mapping form of the variant
+func (v *Variant) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*v = Variant{}
+		return value.Decode(&v.Content)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			if key := value.Content[i]; key.Value != "content" && key.Value != "weight" {
+				return fmt.Errorf("line %d: unknown variant field %q (want content or weight)", key.Line, key.Value)
+			}
+		}
+		var m struct {
+			Content string `yaml:"content"`
+			Weight  int    `yaml:"weight"`
+		}
+		if err := value.Decode(&m); err != nil {
+			return err
+		}
+		*v = Variant{Content: m.Content, Weight: m.Weight}
+		return nil
+	}
+	return fmt.Errorf("line %d: variant must be a string or a mapping", value.Line)
+}
+
+// Encodes the variant in the same form it is parsed from
+func (v Variant) MarshalYAML() (interface{}, error) {
+	if v.Weight == 0 {
+		return v.Content, nil
+	}
+	return map[string]interface{}{"content": v.Content, "weight": v.Weight}, nil
+}
+
+// Returns the weight of the variant
+func (v Variant) WeightOrDefault() int {
+	if v.Weight == 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// Returns the rotation of the post's variants
+func (p Post) RotationOrDefault() Rotation {
+	if p.Rotation == "" {
+		return RotationRoundRobin
+	}
+	return p.Rotation
+}
+
+// Returns the texts the post may be published with: its variants, or its content
+func (p Post) Contents() []string {
+	if len(p.Variants) == 0 {
+		return []string{p.Content}
+	}
+	contents := make([]string, len(p.Variants))
+	for i, variant := range p.Variants {
+		contents[i] = variant.Content
+	}
+	return contents
+}
+
+// Returns the post with its content replaced by the given variant
+func (p Post) WithVariant(index int) Post {
+	p.Content = p.Variants[index].Content
+	return p
+}
+
+// Checks the variant settings of a post for errors
+func (p Post) validateVariants() error {
+	if len(p.Variants) == 0 {
+		if p.Rotation != "" {
+			return fmt.Errorf("rotation requires variants")
+		}
+		return nil
+	}
+	if p.Content != "" {
+		return fmt.Errorf("content and variants are mutually exclusive")
+	}
+	switch p.RotationOrDefault() {
+	case RotationRoundRobin, RotationRandom, RotationLeastRecent:
+	default:
+		return fmt.Errorf("unknown rotation %q (want round_robin, random or least_recent)", p.Rotation)
+	}
+	for i, variant := range p.Variants {
+		if variant.Content == "" {
+			return fmt.Errorf("variant %d: content is required", i+1)
+		}
+		if variant.Weight < 0 {
+			return fmt.Errorf("variant %d: weight must not be negative", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestVariant_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    []Variant
+		wantErr string
+	}{
+		{
+			name: "text and mapping forms",
+			yaml: "- Good morning!\n- content: Morning, all!\n  weight: 3\n",
+			want: []Variant{{Content: "Good morning!"}, {Content: "Morning, all!", Weight: 3}},
+		},
+		{
+			name:    "unknown field",
+			yaml:    "- content: Hi\n  wieght: 2\n",
+			wantErr: `line 2: unknown variant field "wieght" (want content or weight)`,
+		},
+		{
+			name:    "sequence",
+			yaml:    "- [a, b]\n",
+			wantErr: "line 1: variant must be a string or a mapping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Variant
+			err := yaml.Unmarshal([]byte(tt.yaml), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Unmarshal() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() unexpected error = %v", err)
+			}
+			if len(got) != len(tt.want) || got[0] != tt.want[0] || got[1] != tt.want[1] {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateVariants(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+	variants := []Variant{{Content: "Hello"}, {Content: "Hi"}}
+
+	tests := []struct {
+		name    string
+		post    Post
+		wantErr string
+	}{
+		{
+			name: "variants without content",
+			post: Post{ID: "greeting", Variants: variants, Rotation: RotationLeastRecent, ScheduledAt: at},
+		},
+		{
+			name:    "content and variants",
+			post:    Post{ID: "greeting", Content: "Hey", Variants: variants, ScheduledAt: at},
+			wantErr: "post greeting: content and variants are mutually exclusive",
+		},
+		{
+			name:    "unknown rotation",
+			post:    Post{ID: "greeting", Variants: variants, Rotation: "shuffle", ScheduledAt: at},
+			wantErr: `post greeting: unknown rotation "shuffle" (want round_robin, random or least_recent)`,
+		},
+		{
+			name:    "rotation without variants",
+			post:    Post{ID: "greeting", Content: "Hey", Rotation: RotationRandom, ScheduledAt: at},
+			wantErr: "post greeting: rotation requires variants",
+		},
+		{
+			name:    "empty variant",
+			post:    Post{ID: "greeting", Variants: []Variant{{Content: "Hello"}, {Weight: 2}}, ScheduledAt: at},
+			wantErr: "post greeting: variant 2: content is required",
+		},
+		{
+			name: "variant template error",
+			post: Post{ID: "greeting", Variants: []Variant{{Content: "Hello"}, {Content: "{{.Vars.name"}},
+				Template: true, ScheduledAt: at},
+			wantErr: "post greeting variant 2: invalid template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Posts: []Post{tt.post}}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want starting with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
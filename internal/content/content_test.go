@@ -0,0 +1,320 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	at := time.Date(2030, 4, 28, 9, 0, 0, 0, time.UTC)
+	data := Data{
+		ID:          "countdown",
+		Account:     "events",
+		ScheduledAt: at,
+		Time:        at.Add(5 * time.Minute),
+		Vars:        map[string]string{"event": "GopherCon", "start": "2030-05-01"},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "plain text",
+			text: "No template here",
+			want: "No template here",
+		},
+		{
+			name: "countdown from variable",
+			text: `{{.Vars.event}} starts in {{daysUntil .Vars.start}} days!`,
+			want: "GopherCon starts in 3 days!",
+		},
+		{
+			name: "day of the conference",
+			text: `Day {{daysSince "2030-04-27" | ordinal}} on {{weekday .Time}}`,
+			want: "Day 1st on Sunday",
+		},
+		{
+			name: "dates",
+			text: `{{date "Jan 2 15:04" .Time}} / {{addDays 7 .ScheduledAt | date "2006-01-02"}} by @{{.Account}}`,
+			want: "Apr 28 09:05 / 2030-05-05 by @events",
+		},
+		{
+			name: "ordinals",
+			text: `{{ordinal 1}} {{ordinal 2}} {{ordinal 3}} {{ordinal 11}} {{ordinal 22}} {{ordinal 113}}`,
+			want: "1st 2nd 3rd 11th 22nd 113th",
+		},
+		{
+			name:    "syntax error",
+			text:    "{{.Vars.event",
+			wantErr: "invalid template",
+		},
+		{
+			name:    "unknown variable",
+			text:    "{{.Vars.venue}}",
+			wantErr: `map has no entry for key "venue"`,
+		},
+		{
+			name:    "invalid date",
+			text:    `{{daysUntil "May 1"}}`,
+			wantErr: `invalid date "May 1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.text, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Render() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantAll bool
+	}{
+		{name: "no vars", text: "Posted {{date \"Jan 2\" .Time}}", want: ""},
+		{name: "fields", text: `{{.Vars.event}} in {{daysUntil .Vars.start}} days, {{.Vars.event}}`, want: "event,start"},
+		{name: "inside blocks", text: `{{if .Vars.a}}{{with .ID}}{{$.Vars.b}}{{end}}{{else}}{{range .Vars.c}}{{end}}{{end}}`, want: "a,b,c"},
+		{name: "index", text: `{{index .Vars "event"}}`, want: "", wantAll: true},
+		{name: "range over vars", text: `{{range $k, $v := .Vars}}{{$v}}{{end}}`, want: "", wantAll: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, all, err := TemplateVars(tt.text)
+			if err != nil {
+				t.Fatalf("TemplateVars() error = %v", err)
+			}
+			if got := strings.Join(names, ","); got != tt.want || all != tt.wantAll {
+				t.Errorf("TemplateVars() = %q, %v, want %q, %v", got, all, tt.want, tt.wantAll)
+			}
+		})
+	}
+
+	if _, _, err := TemplateVars("{{.Vars.event"); err == nil {
+		t.Error("TemplateVars() expected error for an invalid template")
+	}
+}
+
+func TestWeightedLength(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"ascii", "Hello, world", 12},
+		{"accented latin", "Café", 4},
+		{"japanese", "こんにちは", 10},
+		{"emoji", "🎉", 2},
+		{"link counts as 23", "See https://example.com/a/very/long/path/that/keeps/going.", 4 + 23 + 1},
+		{"two links", "https://a.example https://b.example", 23 + 1 + 23},
+		{"curly quotes", "“quoted”", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeightedLength(tt.text); got != tt.want {
+				t.Errorf("WeightedLength(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	got := URLs("Read https://example.com/post?id=1, then (http://example.org/x).")
+	want := []string{"https://example.com/post?id=1", "http://example.org/x"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("URLs() = %v, want %v", got, want)
+	}
+}
+
+func TestEntities(t *testing.T) {
+	text := "#Go1 and #gophers meet @golang_team! Not #1, a#b, mail@example.com or https://example.com/#top @x"
+	if got := fmt.Sprint(Hashtags(text)); got != "[Go1 gophers]" {
+		t.Errorf("Hashtags() = %s, want [Go1 gophers]", got)
+	}
+	if got := fmt.Sprint(Mentions(text)); got != "[golang_team x]" {
+		t.Errorf("Mentions() = %s, want [golang_team x]", got)
+	}
+	if got := fmt.Sprint(Words("NEW: #Go @x_y https://example.com/ABC")); got != "[NEW #Go @x_y]" {
+		t.Errorf("Words() = %s, want [NEW #Go @x_y]", got)
+	}
+}
+
+func TestTagURLs(t *testing.T) {
+	params := []Param{{"utm_source", "x"}, {"utm_medium", "social"}, {"utm_campaign", "spring launch"}, {"utm_term", ""}}
+
+	tests := []struct {
+		name  string
+		text  string
+		hosts []string
+		want  string
+	}{
+		{
+			name: "plain link",
+			text: "Read https://example.com/post.",
+			want: "Read https://example.com/post?utm_source=x&utm_medium=social&utm_campaign=spring+launch.",
+		},
+		{
+			name: "keeps query and fragment",
+			text: "https://example.com/a?id=1&q=%E3%81%82#section-2",
+			want: "https://example.com/a?id=1&q=%E3%81%82&utm_source=x&utm_medium=social&utm_campaign=spring+launch#section-2",
+		},
+		{
+			name: "keeps existing parameters",
+			text: "https://example.com/?utm_source=newsletter",
+			want: "https://example.com/?utm_source=newsletter&utm_medium=social&utm_campaign=spring+launch",
+		},
+		{
+			name:  "only listed hosts and their subdomains",
+			text:  "https://blog.example.com/x and https://other.org/y",
+			hosts: []string{"example.com"},
+			want:  "https://blog.example.com/x?utm_source=x&utm_medium=social&utm_campaign=spring+launch and https://other.org/y",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TagURLs(tt.text, params, tt.hosts); got != tt.want {
+				t.Errorf("TagURLs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "heading and paragraph",
+			markdown: "# Big News\n\nWe are **launching** today,\nand _you_ are invited!\n",
+			want:     "Big News\n\nWe are launching today, and you are invited!",
+		},
+		{
+			name:     "links",
+			markdown: "Read [the post](https://example.com/post) or <https://example.com/faq>.\n[https://example.com](https://example.com)",
+			want:     "Read the post (https://example.com/post) or https://example.com/faq. https://example.com",
+		},
+		{
+			name:     "lists",
+			markdown: "What's new:\n\n* Faster `sync`\n+ Dark mode\n1. Fewer *bugs*\n",
+			want:     "What's new:\n\n- Faster sync\n- Dark mode\n1. Fewer bugs",
+		},
+		{
+			name:     "snake case and escapes survive",
+			markdown: "Set max_gap_size in config\\_file \\*now\\*",
+			want:     "Set max_gap_size in config_file *now*",
+		},
+		{
+			name:     "quotes, rules, images and hard breaks",
+			markdown: "> Quoted line\n\n---\n\n![logo](logo.png) Line one  \nLine two\\\nLine three",
+			want:     "Quoted line\n\nlogo Line one\nLine two\nLine three",
+		},
+		{
+			name:     "code fence keeps lines",
+			markdown: "Run:\n```\ngo install\nx-scheduler -validate\n```",
+			want:     "Run:\ngo install\nx-scheduler -validate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.markdown); got != tt.want {
+				t.Errorf("PlainText() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	sentence := "This sentence is exactly fifty characters long!!. "
+	long := strings.Repeat(sentence, 8)
+
+	tests := []struct {
+		name     string
+		text     string
+		numbered bool
+		want     []int // Weighted length of each part
+		check    func(t *testing.T, parts []string)
+	}{
+		{
+			name: "short text is unchanged",
+			text: "Short and sweet",
+			want: []int{15},
+		},
+		{
+			name: "splits at sentence ends",
+			text: long,
+			want: []int{249, 149},
+			check: func(t *testing.T, parts []string) {
+				if !strings.HasSuffix(parts[0], "long!!.") || !strings.HasPrefix(parts[1], "This sentence") {
+					t.Errorf("parts do not end at a sentence: %q", parts)
+				}
+			},
+		},
+		{
+			name:     "numbered parts",
+			text:     long,
+			numbered: true,
+			want:     []int{255, 155},
+			check: func(t *testing.T, parts []string) {
+				if !strings.HasSuffix(parts[0], "long!!. (1/2)") || !strings.HasSuffix(parts[1], "long!!. (2/2)") {
+					t.Errorf("parts are not numbered: %q", parts)
+				}
+			},
+		},
+		{
+			name: "prefers paragraphs",
+			text: strings.Repeat("word ", 30) + "end.\n\n" + strings.Repeat("more ", 60),
+			want: []int{154, 279, 19},
+		},
+		{
+			name: "keeps links whole",
+			text: strings.Repeat("x", 270) + " https://example.com/page more",
+			want: []int{270, 28},
+		},
+		{
+			name: "cuts words without spaces",
+			text: strings.Repeat("あ", 200),
+			want: []int{280, 120},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := Split(tt.text, tt.numbered)
+			var got []int
+			for _, part := range parts {
+				got = append(got, WeightedLength(part))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Split() lengths = %v, want %v\nparts: %q", got, tt.want, parts)
+			}
+			if tt.check != nil {
+				tt.check(t, parts)
+			}
+		})
+	}
+}
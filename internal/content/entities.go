@@ -0,0 +1,40 @@
+package content
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// Hashtags and mentions must not follow a word character, and hashtags
+	// need a letter so that "#1" is not one
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]*[\p{L}_][\p{L}\p{N}_]*)`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([A-Za-z0-9_]{1,15})`)
+)
+
+// Returns the hashtags in text without the leading #, ignoring links
+func Hashtags(text string) []string {
+	return submatches(hashtagPattern, text)
+}
+
+// Returns the mentioned usernames in text without the leading @, ignoring links
+func Mentions(text string) []string {
+	return submatches(mentionPattern, text)
+}
+
+// Returns the first group of every match outside links
+func submatches(pattern *regexp.Regexp, text string) []string {
+	var found []string
+	for _, m := range pattern.FindAllStringSubmatch(urlPattern.ReplaceAllString(text, " "), -1) {
+		found = append(found, m[1])
+	}
+	return found
+}
+
+// Returns the words in text outside links, keeping the # of hashtags and the @ of mentions
+func Words(text string) []string {
+	return strings.FieldsFunc(urlPattern.ReplaceAllString(text, " "), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '#' && r != '@' && r != '_'
+	})
+}
@@ -0,0 +1,55 @@
+package content
+
+import "regexp"
+
+// Maximum weighted length of a post on X
+const MaxLength = 280
+
+// Weighted length X counts for every link, regardless of its actual length
+const urlLength = 23
+
+// Matches http and https links, leaving trailing punctuation outside
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]*[^\s<>".,;:!?'")\]}]`)
+
+// Code point ranges that count as one character; everything else counts as two
+var lightRanges = [][2]rune{
+	{0x0000, 0x10FF}, // Latin, Greek, Cyrillic, Hebrew, Arabic and more
+	{0x2000, 0x200D}, // Spaces and joiners
+	{0x2010, 0x201F}, // Dashes and quotation marks
+	{0x2032, 0x2037}, // Primes
+}
+
+// Returns the links in text in order of appearance
+func URLs(text string) []string {
+	return urlPattern.FindAllString(text, -1)
+}
+
+// Returns the length of text as counted by X
+//
+// Links count as 23 characters and CJK characters and emoji as two, following
+// the twitter-text rules. Emoji sequences joined by zero width joiners are
+// counted per code point, so the result may be slightly higher than X's.
+func WeightedLength(text string) int {
+	length := 0
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		length += weightedRunes(text[last:loc[0]]) + urlLength
+		last = loc[1]
+	}
+	return length + weightedRunes(text[last:])
+}
+
+// Returns the weighted length of text without links
+func weightedRunes(text string) int {
+	length := 0
+	for _, r := range text {
+		length += 2
+		for _, light := range lightRanges {
+			if r >= light[0] && r <= light[1] {
+				length--
+				break
+			}
+		}
+	}
+	return length
+}
@@ -0,0 +1,115 @@
+package content
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Start of the private use area characters escaped in the Markdown are mapped to
+const escapeBase = 0xE000
+
+var (
+	headingPattern  = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+	listPattern     = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	rulePattern     = regexp.MustCompile(`^\s*([-*_]\s*){3,}$`)
+	imagePattern    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	autolinkPattern = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	codePattern     = regexp.MustCompile("`([^`]+)`")
+	strongPattern   = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	emPattern       = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:.*?\S)?)[*_]($|[^\w*])`)
+	escapePattern   = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!>~|])`)
+)
+
+// Converts Markdown to plain post text
+//
+// Headings, emphasis, code spans, quotes and fences are reduced to their
+// text, links become "text (url)" and lines of a paragraph are joined as
+// Markdown renders them. List items and blank lines between paragraphs are
+// kept.
+func PlainText(markdown string) string {
+	var blocks []string
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	inFence := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			// Code blocks keep their line breaks
+			blocks = append(blocks, line)
+			continue
+		}
+
+		trimmed = strings.TrimSpace(strings.TrimLeft(trimmed, ">"))
+		switch {
+		case trimmed == "":
+			flush()
+			if len(blocks) > 0 && blocks[len(blocks)-1] != "" {
+				blocks = append(blocks, "")
+			}
+		case rulePattern.MatchString(trimmed):
+			flush()
+		case headingPattern.MatchString(trimmed):
+			flush()
+			blocks = append(blocks, inline(headingPattern.ReplaceAllString(trimmed, "$1")))
+		case listPattern.MatchString(line):
+			flush()
+			marker := strings.TrimSpace(listPattern.FindString(line))
+			if strings.ContainsAny(marker, "*+") {
+				marker = "-"
+			}
+			blocks = append(blocks, marker+" "+inline(listPattern.ReplaceAllString(line, "")))
+		default:
+			// Two trailing spaces or a backslash force a line break
+			if strings.HasSuffix(line, "  ") || strings.HasSuffix(trimmed, "\\") {
+				paragraph = append(paragraph, inline(strings.TrimSuffix(trimmed, "\\")))
+				flush()
+				continue
+			}
+			paragraph = append(paragraph, inline(trimmed))
+		}
+	}
+	flush()
+
+	return strings.TrimSpace(strings.Join(blocks, "\n"))
+}
+
+// Strips inline Markdown from a line of text
+func inline(text string) string {
+	// Escaped characters are hidden in the private use area until the end,
+	// so that they are not taken for markup
+	text = escapePattern.ReplaceAllStringFunc(text, func(escaped string) string {
+		return string(escapeBase + rune(escaped[1]))
+	})
+
+	text = imagePattern.ReplaceAllString(text, "$1")
+	text = linkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		m := linkPattern.FindStringSubmatch(link)
+		if m[1] == m[2] {
+			return m[2]
+		}
+		return m[1] + " (" + m[2] + ")"
+	})
+	text = autolinkPattern.ReplaceAllString(text, "$1")
+	text = codePattern.ReplaceAllString(text, "$1")
+	text = strongPattern.ReplaceAllString(text, "$2")
+	text = emPattern.ReplaceAllString(text, "$1$2$3")
+	return strings.Map(func(r rune) rune {
+		if r > escapeBase && r < escapeBase+0x80 {
+			return r - escapeBase
+		}
+		return r
+	}, text)
+}
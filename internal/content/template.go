@@ -0,0 +1,199 @@
+// Package content renders post content and measures it the way X does.
+package content
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Represents the values available to a content template
+type Data struct {
+	ID          string            // Post identifier
+	Account     string            // Account the post is published on
+	Lang        string            // Language tag of the text, if localized
+	ScheduledAt time.Time         // Configured posting time
+	Time        time.Time         // Time the post is published
+	Vars        map[string]string // Config-level variables
+}
+
+// Renders text as a Go text/template
+//
+// Besides the fields of Data, templates can use these functions:
+//
+//	date "Jan 2" .Time      formats a time with a Go layout
+//	weekday .Time           returns the weekday name, e.g. Monday
+//	daysUntil "2030-05-01"  returns the days from the posting day to a date
+//	daysSince "2030-05-01"  returns the days from a date to the posting day
+//	addDays 3 .Time         shifts a time by whole days
+//	ordinal 2               returns 2nd
+//
+// Unknown variables are errors rather than empty strings.
+func Render(text string, data Data) (string, error) {
+	tmpl, err := template.New("content").
+		Option("missingkey=error").
+		Funcs(funcs(data.Time)).
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return out.String(), nil
+}
+
+// Returns the names of the vars text uses as .Vars.name, sorted
+//
+// all is true if the template uses .Vars in any other way, e.g. with index
+// or range, so that any var may affect the output.
+func TemplateVars(text string) (names []string, all bool, err error) {
+	tmpl, err := template.New("content").Funcs(funcs(time.Time{})).Parse(text)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid template: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var visit func(node parse.Node)
+	use := func(ident []string) {
+		for i, name := range ident {
+			if name != "Vars" {
+				continue
+			}
+			if i+1 < len(ident) {
+				seen[ident[i+1]] = true
+			} else {
+				all = true
+			}
+			return
+		}
+	}
+	visit = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				visit(child)
+			}
+		case *parse.ActionNode:
+			visit(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				visit(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				visit(arg)
+			}
+		case *parse.ChainNode:
+			visit(n.Node)
+		case *parse.FieldNode:
+			use(n.Ident)
+		case *parse.VariableNode:
+			use(n.Ident)
+		case *parse.IfNode:
+			visit(n.Pipe)
+			visit(n.List)
+			visit(n.ElseList)
+		case *parse.RangeNode:
+			visit(n.Pipe)
+			visit(n.List)
+			visit(n.ElseList)
+		case *parse.WithNode:
+			visit(n.Pipe)
+			visit(n.List)
+			visit(n.ElseList)
+		case *parse.TemplateNode:
+			visit(n.Pipe)
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			visit(t.Tree.Root)
+		}
+	}
+
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, all, nil
+}
+
+// Returns the template functions, with countdowns relative to now
+func funcs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"date": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"weekday": func(t time.Time) string {
+			return t.Weekday().String()
+		},
+		"daysUntil": func(date interface{}) (int, error) {
+			t, err := toDate(date, now.Location())
+			if err != nil {
+				return 0, err
+			}
+			return daysBetween(now, t), nil
+		},
+		"daysSince": func(date interface{}) (int, error) {
+			t, err := toDate(date, now.Location())
+			if err != nil {
+				return 0, err
+			}
+			return daysBetween(t, now), nil
+		},
+		"addDays": func(days int, t time.Time) time.Time {
+			return t.AddDate(0, 0, days)
+		},
+		"ordinal": ordinal,
+	}
+}
+
+// Converts a YYYY-MM-DD string or a time to a time in loc
+func toDate(value interface{}, loc *time.Location) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.In(loc), nil
+	case string:
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", v)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %v (want YYYY-MM-DD or a time)", value)
+}
+
+// Returns the number of calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	// Counting in UTC avoids off-by-one errors across DST changes
+	from := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// Returns n with its English ordinal suffix, e.g. 1st, 12th or 23rd
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
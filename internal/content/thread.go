@@ -0,0 +1,105 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Matches the end of a sentence followed by whitespace
+var sentenceEndPattern = regexp.MustCompile(`[.!?。！？]["'”’)\]]*\s`)
+
+// Splits text into parts that each fit into a post, for posting as a thread
+//
+// Parts end at paragraph, sentence or word boundaries, in that order of
+// preference, and links are never broken. With numbered, each part gets a
+// " (1/3)" suffix that counts towards its length. Text that fits into a
+// single post is returned unchanged.
+func Split(text string, numbered bool) []string {
+	text = strings.TrimSpace(text)
+	if WeightedLength(text) <= MaxLength {
+		return []string{text}
+	}
+	if !numbered {
+		return splitParts(text, MaxLength)
+	}
+
+	// The suffix length depends on the number of parts, so grow it until
+	// the parts fit the space it leaves
+	for digits := 1; ; digits++ {
+		suffix := len(" (/)") + 2*digits
+		parts := splitParts(text, MaxLength-suffix)
+		if len(fmt.Sprint(len(parts))) > digits {
+			continue
+		}
+		for i := range parts {
+			parts[i] += fmt.Sprintf(" (%d/%d)", i+1, len(parts))
+		}
+		return parts
+	}
+}
+
+// Splits text greedily into parts of at most budget weighted characters
+func splitParts(text string, budget int) []string {
+	var parts []string
+	for text != "" {
+		if WeightedLength(text) <= budget {
+			parts = append(parts, text)
+			break
+		}
+		cut := cutPoint(text, budget)
+		parts = append(parts, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	return parts
+}
+
+// Returns the byte offset at which to end the next part of text
+func cutPoint(text string, budget int) int {
+	// Find the longest prefix that fits
+	limit := 0
+	for i := range text {
+		if i > 0 && WeightedLength(text[:i]) > budget {
+			break
+		}
+		limit = i
+	}
+
+	// Never cut through a link; a link alone always fits
+	for _, span := range urlPattern.FindAllStringIndex(text, -1) {
+		if span[0] < limit && limit < span[1] && span[0] > 0 {
+			limit = span[0]
+		}
+	}
+
+	// Prefer the strongest boundary that still fills at least half the part
+	prefix := text[:limit]
+	boundaries := []int{
+		strings.LastIndex(prefix, "\n\n"),
+		lastSentenceEnd(prefix),
+		strings.LastIndexFunc(prefix, unicode.IsSpace),
+	}
+	fallback := 0
+	for _, boundary := range boundaries {
+		if boundary >= limit/2 {
+			return boundary
+		}
+		if boundary > fallback {
+			fallback = boundary
+		}
+	}
+	if fallback > 0 {
+		return fallback
+	}
+	return limit
+}
+
+// Returns the offset just after the last sentence end in text, or -1
+func lastSentenceEnd(text string) int {
+	ends := sentenceEndPattern.FindAllStringIndex(text, -1)
+	if len(ends) == 0 {
+		return -1
+	}
+	return ends[len(ends)-1][1]
+}
@@ -0,0 +1,73 @@
+package content
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Represents a query parameter added to links
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Adds the parameters to the links in text whose host is one of hosts or a
+// subdomain of one (every link if hosts is empty)
+//
+// Parameters a link already has are left alone, and its other query
+// parameters and fragment are kept as written. Parameters with an empty
+// value are skipped.
+func TagURLs(text string, params []Param, hosts []string) string {
+	return urlPattern.ReplaceAllStringFunc(text, func(link string) string {
+		return tagURL(link, params, hosts)
+	})
+}
+
+// Adds the parameters to a single link
+func tagURL(link string, params []Param, hosts []string) string {
+	u, err := url.Parse(link)
+	if err != nil || !matchesHost(u.Hostname(), hosts) {
+		return link
+	}
+
+	existing := u.Query()
+	var added []string
+	for _, param := range params {
+		if param.Value == "" || existing.Has(param.Name) {
+			continue
+		}
+		added = append(added, url.QueryEscape(param.Name)+"="+url.QueryEscape(param.Value))
+	}
+	if len(added) == 0 {
+		return link
+	}
+
+	// The link is edited as text so that its escaping stays as written
+	base, fragment, hasFragment := strings.Cut(link, "#")
+	switch {
+	case !strings.Contains(base, "?"):
+		base += "?"
+	case !strings.HasSuffix(base, "?") && !strings.HasSuffix(base, "&"):
+		base += "&"
+	}
+	base += strings.Join(added, "&")
+	if hasFragment {
+		return base + "#" + fragment
+	}
+	return base
+}
+
+// Reports whether host is one of hosts or a subdomain of one
+func matchesHost(host string, hosts []string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, candidate := range hosts {
+		candidate = strings.ToLower(candidate)
+		if host == candidate || strings.HasSuffix(host, "."+candidate) {
+			return true
+		}
+	}
+	return false
+}
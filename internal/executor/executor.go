@@ -3,28 +3,56 @@ package executor
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/content"
 	"github.com/zinrai/x-scheduler/internal/poster"
+	"github.com/zinrai/x-scheduler/internal/state"
 	"github.com/zinrai/x-scheduler/pkg/logger"
 )
 
 // Represents a post with its execution time
 type ScheduledPost struct {
-	Post      config.Post
-	ExecuteAt time.Time
+	Post         config.Post
+	ExecuteAt    time.Time
+	JitterOffset time.Duration // Shift applied to the scheduled time
+	JitterSeed   int64         // Seed the shift was derived from
+	Adjustments  []string      // Reasons the execution time was changed after jitter
+	Content      string        // Content as published, with templates rendered
+	Variant      int           // Number of the chosen variant, starting at 1 (0 without variants)
+	Thread       []string      // Parts of content too long for one post, posted as a reply chain
+}
+
+// Returns the texts to post in order: the thread parts, or the content
+func (sp ScheduledPost) Parts() []string {
+	if len(sp.Thread) > 0 {
+		return sp.Thread
+	}
+	return []string{sp.Content}
+}
+
+// Returns the scheduled time shifted by jitter
+func (sp ScheduledPost) PlannedAt() time.Time {
+	return sp.Post.ScheduledAt.Add(sp.JitterOffset)
 }
 
 // Handles the execution of scheduled posts
 type Executor struct {
-	jobQueue chan ScheduledPost
+	jobQueue  chan ScheduledPost
+	store     *state.Store // Run state for idempotency (nil disables tracking)
+	pause     *PauseController
+	pauseMode config.PauseMode
+	post      func(content string, opts poster.Options) (string, error) // Posts a tweet, replaced in tests
 }
 
 // Creates a new executor instance
-func NewExecutor() *Executor {
+func NewExecutor(store *state.Store) *Executor {
 	return &Executor{
 		jobQueue: make(chan ScheduledPost, 100), // Buffer for up to 100 posts
+		store:    store,
+		post:     poster.Post,
 	}
 }
 
@@ -37,8 +65,8 @@ func (e *Executor) Execute(cfg *config.Config) error {
 		return fmt.Errorf("poster validation failed: %w", err)
 	}
 
-	// Get future posts for today
-	futurePosts := e.getFuturePosts(cfg)
+	// Get future posts for today, sorted by execution time
+	futurePosts, _ := e.Plan(cfg)
 	if len(futurePosts) == 0 {
 		logger.Info("No posts scheduled for execution")
 		return nil
@@ -46,16 +74,231 @@ func (e *Executor) Execute(cfg *config.Config) error {
 
 	logger.Info("Found %d posts scheduled for execution", len(futurePosts))
 
-	// Sort posts by execution time
-	sort.Slice(futurePosts, func(i, j int) bool {
-		return futurePosts[i].ExecuteAt.Before(futurePosts[j].ExecuteAt)
-	})
+	// Allow pending posts to be paused while waiting
+	e.pause = NewPauseController(cfg.PauseFile())
+	e.pauseMode = cfg.Pause.ModeOrDefault()
+	stopSignals := e.pause.WatchSignals()
+	defer stopSignals()
 
 	// Queue all posts
 	e.queuePosts(futurePosts)
 
 	// Process queue sequentially
-	return e.processQueue()
+	return e.processQueue(cfg)
+}
+
+// Returns the posts to execute today sorted by execution time, and the
+// posts that were planned but will not be executed
+func (e *Executor) Plan(cfg *config.Config) ([]ScheduledPost, []SuppressedPost) {
+	futurePosts := e.getFuturePosts(cfg)
+	var suppressed []SuppressedPost
+
+	// Stable sort keeps configuration order for posts at the same time
+	sort.SliceStable(futurePosts, func(i, j int) bool {
+		return futurePosts[i].ExecuteAt.Before(futurePosts[j].ExecuteAt)
+	})
+
+	// Posts the run state already records are skipped at execution, and
+	// those that were posted are counted from the run state by the caps
+	futurePosts = e.withoutRecorded(futurePosts)
+
+	// Posts awaiting approval are never published and take up no capacity
+	futurePosts, suppressed = applyApprovals(cfg, futurePosts, suppressed)
+
+	// Blacked out posts must not take up capacity under the caps
+	futurePosts, suppressed = applyBlackouts(cfg, futurePosts, suppressed)
+
+	// Jitter and catch-ups can create conflicts the configured times did not
+	// have; the policy decides whether they are shifted or suppressed
+	if cfg.Spacing != nil {
+		var dropped []SuppressedPost
+		futurePosts, dropped = ApplySpacing(futurePosts, cfg.Spacing.GapFor, cfg.Spacing.PolicyOrDefault())
+		for _, droppedPost := range dropped {
+			logger.Warn("Rejected post %s: %s", droppedPost.Post.Identifier(), droppedPost.Reason)
+		}
+		suppressed = append(suppressed, dropped...)
+	}
+
+	// Caps are checked on the times spacing left, and count what was already
+	// posted today according to the run state
+	if cfg.Limits != nil {
+		var posted map[string][]time.Time
+		if e.store != nil {
+			now := time.Now()
+			posted = e.store.PostedSince(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+		}
+
+		var rejected []SuppressedPost
+		futurePosts, rejected = ApplyLimits(futurePosts, cfg.Limits.For, cfg.Limits.OverflowOrDefault(), posted, cfg.Spacing.GapFor)
+		for _, rejectedPost := range rejected {
+			logger.Warn("Rejected post %s: %s", rejectedPost.Post.Identifier(), rejectedPost.Reason)
+		}
+		suppressed = append(suppressed, rejected...)
+	}
+
+	// Deferred and shifted posts may have moved into a blackout
+	futurePosts, suppressed = applyBlackouts(cfg, futurePosts, suppressed)
+
+	// Templates see the final execution time
+	futurePosts, suppressed = e.renderContent(cfg, futurePosts, suppressed)
+
+	for _, scheduledPost := range futurePosts {
+		for _, adjustment := range scheduledPost.Adjustments {
+			logger.Info("Adjusted post %s (%s)", scheduledPost.Post.Identifier(), adjustment)
+		}
+	}
+
+	return futurePosts, suppressed
+}
+
+// Leaves out posts the run state records as posted or with an attempt of
+// unknown outcome
+func (e *Executor) withoutRecorded(posts []ScheduledPost) []ScheduledPost {
+	if e.store == nil {
+		return posts
+	}
+
+	var kept []ScheduledPost
+	for _, scheduledPost := range posts {
+		post := scheduledPost.Post
+		record, ok := e.store.Get(post.Identifier())
+		if ok {
+			if record.Posted() {
+				logger.Debug("Leaving out already posted post %s", post.Identifier())
+				continue
+			}
+			if last, ok := record.LastAttempt(); ok && last.Outcome == state.OutcomePending {
+				logger.Debug("Leaving out post %s with unknown outcome", post.Identifier())
+				continue
+			}
+		}
+		kept = append(kept, scheduledPost)
+	}
+	return kept
+}
+
+// Moves posts the approval workflow holds back to suppressed
+func applyApprovals(cfg *config.Config, posts []ScheduledPost, suppressed []SuppressedPost) ([]ScheduledPost, []SuppressedPost) {
+	var kept []ScheduledPost
+	for _, scheduledPost := range posts {
+		post := scheduledPost.Post
+		reason := cfg.ApprovalBlock(post)
+		if reason == "" {
+			kept = append(kept, scheduledPost)
+			continue
+		}
+
+		logger.Warn("Refusing to publish post %s: %s", post.Identifier(), reason)
+		suppressed = append(suppressed, SuppressedPost{
+			Post:   post,
+			At:     scheduledPost.ExecuteAt,
+			Reason: reason,
+		})
+	}
+
+	return kept, suppressed
+}
+
+// Moves posts whose execution time falls within a blackout to suppressed
+func applyBlackouts(cfg *config.Config, posts []ScheduledPost, suppressed []SuppressedPost) ([]ScheduledPost, []SuppressedPost) {
+	if len(cfg.Blackouts) == 0 {
+		return posts, suppressed
+	}
+
+	var kept []ScheduledPost
+	for _, scheduledPost := range posts {
+		post := scheduledPost.Post
+		blackout := cfg.BlackoutFor(post, scheduledPost.ExecuteAt)
+		if blackout == nil {
+			if post.IgnoreBlackout && cfg.BlackoutFor(config.Post{}, scheduledPost.ExecuteAt) != nil {
+				logger.Info("Post %s ignores blackout at %s", post.Identifier(), scheduledPost.ExecuteAt.Format("15:04:05"))
+			}
+			kept = append(kept, scheduledPost)
+			continue
+		}
+
+		logger.Info("Suppressing post %s: %s (at %s)",
+			post.Identifier(), blackout, scheduledPost.ExecuteAt.Format("15:04:05"))
+		suppressed = append(suppressed, SuppressedPost{
+			Post:   post,
+			At:     scheduledPost.ExecuteAt,
+			Reason: blackout.String(),
+		})
+	}
+
+	return kept, suppressed
+}
+
+// Chooses a variant for each post and renders its content, moving posts
+// whose template fails to suppressed
+func (e *Executor) renderContent(cfg *config.Config, posts []ScheduledPost, suppressed []SuppressedPost) ([]ScheduledPost, []SuppressedPost) {
+	// Posts sharing variants in this run must see each other's choices
+	rotations := make(map[string]state.Rotation)
+
+	var kept []ScheduledPost
+	for _, scheduledPost := range posts {
+		post := scheduledPost.Post
+		if len(post.Variants) > 0 {
+			key := RotationKey(post.Variants)
+			history, ok := rotations[key]
+			if !ok && e.store != nil {
+				history = e.store.Rotation(key)
+			}
+			seed := JitterSeed(post.Identifier(), scheduledPost.ExecuteAt) + int64(history.Selections)
+			index := ChooseVariant(post.Variants, post.RotationOrDefault(), history, seed)
+			history.Use(VariantKey(post.Variants[index]), scheduledPost.ExecuteAt)
+			rotations[key] = history
+
+			scheduledPost.Variant = index + 1
+			post = post.WithVariant(index)
+			logger.Debug("Variant for post %s: %d of %d (%s)",
+				post.Identifier(), scheduledPost.Variant, len(post.Variants), post.RotationOrDefault())
+		}
+
+		rendered, err := cfg.RenderContent(post, scheduledPost.ExecuteAt)
+		if err != nil {
+			logger.Error("Suppressing post %s: %v", post.Identifier(), err)
+			suppressed = append(suppressed, SuppressedPost{
+				Post:   scheduledPost.Post,
+				At:     scheduledPost.ExecuteAt,
+				Reason: err.Error(),
+			})
+			continue
+		}
+
+		// A lint error holds back only the post it is about
+		if problem, ok := lintError(cfg, rendered); ok {
+			logger.Error("Suppressing post %s: lint: %s", post.Identifier(), problem)
+			suppressed = append(suppressed, SuppressedPost{
+				Post:   scheduledPost.Post,
+				At:     scheduledPost.ExecuteAt,
+				Reason: "lint: " + problem.String(),
+			})
+			continue
+		}
+
+		scheduledPost.Content = rendered
+		if post.AutoThread {
+			if parts := content.Split(rendered, post.Numbered); len(parts) > 1 {
+				scheduledPost.Thread = parts
+			}
+		}
+		kept = append(kept, scheduledPost)
+	}
+	return kept, suppressed
+}
+
+// Returns the first lint problem of error severity in the text
+func lintError(cfg *config.Config, text string) (config.LintProblem, bool) {
+	if cfg.Lint == nil || cfg.Lint.Validate() != nil {
+		return config.LintProblem{}, false
+	}
+	for _, problem := range cfg.Lint.Problems(text) {
+		if problem.Severity == config.SeverityError {
+			return problem, true
+		}
+	}
+	return config.LintProblem{}, false
 }
 
 // Returns posts scheduled for today that are in the future
@@ -66,7 +309,10 @@ func (e *Executor) getFuturePosts(cfg *config.Config) []ScheduledPost {
 
 	var futurePosts []ScheduledPost
 
-	for _, post := range cfg.GetEnabledPosts() {
+	// Queues fill today's slots with their next items
+	posts := append(cfg.GetEnabledPosts(), e.queuedPosts(cfg, now)...)
+
+	for _, post := range posts {
 		// Test posts are executed immediately regardless of schedule
 		if post.Test {
 			futurePosts = append(futurePosts, ScheduledPost{
@@ -76,38 +322,102 @@ func (e *Executor) getFuturePosts(cfg *config.Config) []ScheduledPost {
 			continue
 		}
 
-		// Check if post is scheduled for today
-		if post.ScheduledAt.After(today) && post.ScheduledAt.Before(tomorrow) {
-			// Only include future posts
-			if post.ScheduledAt.After(now) {
-				futurePosts = append(futurePosts, ScheduledPost{
-					Post:      post,
-					ExecuteAt: post.ScheduledAt,
-				})
-			} else {
-				// Log skipped past posts
-				logger.Info("Skipping past post: %s (scheduled at %s)",
-					truncateContent(post.Content, 30),
-					post.ScheduledAt.Format("15:04:05"))
+		// Include future posts scheduled for today, shifted by their jitter.
+		// Whether a post is past is decided before jitter, so that jitter
+		// never turns an upcoming post into a missed one.
+		if post.ScheduledAt.After(now) {
+			scheduledPost := jitteredPost(post, cfg.JitterFor(post), now)
+			if scheduledPost.ExecuteAt.After(today) && scheduledPost.ExecuteAt.Before(tomorrow) {
+				futurePosts = append(futurePosts, scheduledPost)
 			}
+			continue
+		}
+
+		// Past posts are handled by the missed policy
+		scheduledPost := ScheduledPost{Post: post, ExecuteAt: post.ScheduledAt.In(now.Location())}
+		if e.shouldCatchUp(cfg, scheduledPost, now) {
+			scheduledPost.ExecuteAt = now // Execute immediately
+			futurePosts = append(futurePosts, scheduledPost)
 		}
 	}
 
 	return futurePosts
 }
 
+// Returns the upcoming post with its execution time shifted by jitter, but
+// no earlier than now
+func jitteredPost(post config.Post, jitter time.Duration, now time.Time) ScheduledPost {
+	scheduledAt := post.ScheduledAt.In(now.Location())
+	if jitter <= 0 {
+		return ScheduledPost{Post: post, ExecuteAt: scheduledAt}
+	}
+
+	seed := JitterSeed(post.Identifier(), scheduledAt)
+	executeAt, offset := ApplyJitter(scheduledAt, jitter, seed)
+	if executeAt.Before(now) {
+		executeAt, offset = now, now.Sub(scheduledAt)
+	}
+	logger.Debug("Jitter for post %s: %s (seed %d, planned %s)",
+		post.Identifier(), FormatOffset(offset), seed, executeAt.Format("15:04:05"))
+
+	return ScheduledPost{
+		Post:         post,
+		ExecuteAt:    executeAt,
+		JitterOffset: offset,
+		JitterSeed:   seed,
+	}
+}
+
+// Applies the missed policy to a past post and logs the decision
+func (e *Executor) shouldCatchUp(cfg *config.Config, scheduledPost ScheduledPost, now time.Time) bool {
+	post := scheduledPost.Post
+	policy := cfg.MissedPolicyFor(post)
+	plannedAt := scheduledPost.PlannedAt()
+
+	if !ShouldCatchUp(plannedAt, now, policy) {
+		// Only today's posts are logged; older posts are kept as history
+		if IsToday(plannedAt, now) {
+			logger.Info("Skipping past post %s: %s (scheduled at %s, missed policy: %s)",
+				post.Identifier(),
+				truncateContent(post.Content, 30),
+				plannedAt.Format("15:04:05"),
+				policy)
+		}
+		return false
+	}
+
+	// A post with any recorded attempt was not missed
+	if e.store != nil {
+		if _, ok := e.store.Get(post.Identifier()); ok {
+			logger.Info("Skipping past post %s: already attempted (missed policy: %s)",
+				post.Identifier(), policy)
+			return false
+		}
+	}
+
+	logger.Info("Catching up missed post %s: %s (scheduled at %s, missed policy: %s)",
+		post.Identifier(),
+		truncateContent(post.Content, 30),
+		plannedAt.Format("2006-01-02 15:04:05"),
+		policy)
+	return true
+}
+
 // Adds posts to the processing queue
 func (e *Executor) queuePosts(posts []ScheduledPost) {
 	for _, scheduledPost := range posts {
 		nextPostTime := time.Until(scheduledPost.ExecuteAt)
 		if nextPostTime > 0 {
-			logger.Info("Queuing post: %s (in %v at %s)",
-				truncateContent(scheduledPost.Post.Content, 30),
+			logger.Info("Queuing post %s: %s (in %v at %s%s)",
+				scheduledPost.Post.Identifier(),
+				truncateContent(scheduledPost.Content, 30),
 				nextPostTime.Round(time.Second),
-				scheduledPost.ExecuteAt.Format("15:04:05"))
+				scheduledPost.ExecuteAt.Format("15:04:05"),
+				describeJitter(scheduledPost))
 		} else {
-			logger.Info("Queuing immediate post: %s",
-				truncateContent(scheduledPost.Post.Content, 30))
+			logger.Info("Queuing immediate post %s: %s",
+				scheduledPost.Post.Identifier(),
+				truncateContent(scheduledPost.Content, 30))
 		}
 
 		e.jobQueue <- scheduledPost
@@ -117,17 +427,43 @@ func (e *Executor) queuePosts(posts []ScheduledPost) {
 	close(e.jobQueue)
 }
 
+// Returns a log suffix describing the jitter applied to a post
+func describeJitter(scheduledPost ScheduledPost) string {
+	if scheduledPost.JitterOffset == 0 {
+		return ""
+	}
+	return ", jitter " + FormatOffset(scheduledPost.JitterOffset)
+}
+
 // Processes posts from the queue sequentially
-func (e *Executor) processQueue() error {
+func (e *Executor) processQueue(cfg *config.Config) error {
 	var errors []error
 	successCount := 0
+	skippedCount := 0
 
 	for scheduledPost := range e.jobQueue {
-		// Wait until it's time to post
-		e.waitUntilTime(scheduledPost.ExecuteAt)
+		// Wait until it's time to post, honoring the pause switch right
+		// before posting
+		scheduledPost, ok := e.awaitTurn(cfg, scheduledPost)
+		if !ok {
+			skippedCount++
+			continue
+		}
+
+		// Consult run state right before posting
+		done, ok, err := e.beginAttempt(scheduledPost)
+		if err != nil {
+			logger.Error("Failed to execute post: %v", err)
+			errors = append(errors, err)
+			continue
+		}
+		if !ok {
+			skippedCount++
+			continue
+		}
 
 		// Execute the post
-		if err := e.executePost(scheduledPost); err != nil {
+		if err := e.executePost(scheduledPost, done); err != nil {
 			logger.Error("Failed to execute post: %v", err)
 			errors = append(errors, err)
 		} else {
@@ -136,7 +472,8 @@ func (e *Executor) processQueue() error {
 	}
 
 	// Report results
-	logger.Info("Execution completed: %d successful, %d failed", successCount, len(errors))
+	logger.Info("Execution completed: %d successful, %d failed, %d skipped",
+		successCount, len(errors), skippedCount)
 
 	if len(errors) > 0 {
 		return fmt.Errorf("some posts failed: %v", errors)
@@ -145,6 +482,30 @@ func (e *Executor) processQueue() error {
 	return nil
 }
 
+// Waits until the post is due and posting is not paused, re-checking the
+// post after each hold. Returns the post as it should be executed, or false
+// if it was dropped.
+func (e *Executor) awaitTurn(cfg *config.Config, scheduledPost ScheduledPost) (ScheduledPost, bool) {
+	for {
+		e.waitUntilTime(scheduledPost.ExecuteAt)
+
+		held, ok := e.awaitResume(scheduledPost)
+		if !ok {
+			return scheduledPost, false
+		}
+		if !held {
+			return scheduledPost, true
+		}
+
+		var reason string
+		scheduledPost, reason = e.recheckAfterHold(cfg, scheduledPost, time.Now())
+		if reason != "" {
+			logger.Warn("Dropping post %s after hold: %s", scheduledPost.Post.Identifier(), reason)
+			return scheduledPost, false
+		}
+	}
+}
+
 // Waits until the specified time
 func (e *Executor) waitUntilTime(executeAt time.Time) {
 	now := time.Now()
@@ -155,58 +516,309 @@ func (e *Executor) waitUntilTime(executeAt time.Time) {
 	}
 }
 
-// Executes a single post
-func (e *Executor) executePost(scheduledPost ScheduledPost) error {
+// Holds or drops the post while posting is paused. Returns whether the
+// post was held and whether it may still be posted; a hold gives up at the
+// end of the post's day.
+func (e *Executor) awaitResume(scheduledPost ScheduledPost) (held bool, ok bool) {
+	if e.pause == nil {
+		return false, true
+	}
+
+	post := scheduledPost.Post
+	paused, source := e.pause.Paused()
+	if !paused {
+		return false, true
+	}
+
+	if e.pauseMode == config.PauseDrop {
+		logger.Warn("Dropping post %s: posting paused by %s", post.Identifier(), source)
+		return false, false
+	}
+
+	at := scheduledPost.ExecuteAt
+	endOfDay := time.Date(at.Year(), at.Month(), at.Day()+1, 0, 0, 0, 0, at.Location())
+	logger.Info("Holding post %s until posting is resumed", post.Identifier())
+	if !e.pause.WaitWhilePaused(pausePollInterval, endOfDay) {
+		logger.Warn("Dropping post %s: posting still paused at the end of the day", post.Identifier())
+		return true, false
+	}
+	return true, true
+}
+
+// Re-runs the spacing, limit and blackout checks for a post resumed at now,
+// since posts made or windows entered while it was held invalidate the
+// plan. Returns the post with its new execution time, or a reason to drop it.
+func (e *Executor) recheckAfterHold(cfg *config.Config, scheduledPost ScheduledPost, now time.Time) (ScheduledPost, string) {
+	planned := scheduledPost.ExecuteAt
+	scheduledPost.ExecuteAt = now
+	post := scheduledPost.Post
+
+	// Test posts are not spaced or capped
+	if !post.Test {
+		var posted map[string][]time.Time
+		if e.store != nil {
+			posted = e.store.PostedSince(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+		}
+
+		if gap := cfg.Spacing.GapFor(post.Account); gap > 0 {
+			var last time.Time
+			for _, at := range posted[post.Account] {
+				if at.After(last) {
+					last = at
+				}
+			}
+			if earliest := last.Add(gap); !last.IsZero() && now.Before(earliest) {
+				if cfg.Spacing.PolicyOrDefault() == config.SpacingFail {
+					return scheduledPost, fmt.Sprintf("spacing: %v after the last post for account %s (minimum gap %v)",
+						now.Sub(last).Round(time.Second), config.AccountName(post.Account), gap)
+				}
+				scheduledPost.Adjustments = append(scheduledPost.Adjustments, fmt.Sprintf("spacing: moved from %s to keep %v after the last post",
+					now.Format("15:04:05"), gap))
+				scheduledPost.ExecuteAt = earliest
+			}
+		}
+
+		if cfg.Limits != nil {
+			kept, rejected := ApplyLimits([]ScheduledPost{scheduledPost}, cfg.Limits.For, cfg.Limits.OverflowOrDefault(), posted, nil)
+			if len(rejected) > 0 {
+				return scheduledPost, rejected[0].Reason
+			}
+			scheduledPost = kept[0]
+		}
+	}
+
+	if !sameDay(scheduledPost.ExecuteAt, planned) {
+		return scheduledPost, "no slot left today"
+	}
+	if blackout := cfg.BlackoutFor(post, scheduledPost.ExecuteAt); blackout != nil {
+		return scheduledPost, blackout.String()
+	}
+	return scheduledPost, ""
+}
+
+// Reports whether the run state shows the post was already posted, has an
+// attempt with unknown outcome, or left a thread partly posted that has
+// changed since
+func (e *Executor) alreadyAttempted(scheduledPost ScheduledPost) bool {
+	post := scheduledPost.Post
+	if e.store == nil {
+		return false
+	}
+
+	record, ok := e.store.Get(post.Identifier())
+	if !ok {
+		return false
+	}
+
+	if record.Posted() {
+		logger.Info("Skipping already posted post %s: %s (tweet %s)",
+			post.Identifier(), truncateContent(post.Content, 30), strings.Join(record.TweetIDs(), ", "))
+		return true
+	}
+
+	if last, ok := record.LastAttempt(); ok && last.Outcome == state.OutcomePending {
+		logger.Warn("Skipping post %s with unknown outcome from attempt at %s: %s (check X and prune its state key to retry)",
+			post.Identifier(), last.At.Format(time.RFC3339), truncateContent(post.Content, 30))
+		return true
+	}
+
+	// Parts of a changed thread cannot be resumed, and posting it again
+	// would repeat the parts already out
+	if thread, ids, ok := record.PartialThread(); ok && thread != threadKey(scheduledPost.Parts()) {
+		logger.Warn("Skipping post %s: its thread changed after %d part(s) were posted (tweet %s; delete them or prune its state key to retry)",
+			post.Identifier(), len(ids), strings.Join(ids, ", "))
+		return true
+	}
+
+	return false
+}
+
+// Checks the run state and records the start of an attempt in one step,
+// with the state file locked, so that overlapping runs do not both post
+//
+// Returns the IDs of thread parts a previous attempt already posted, or
+// false if the post is to be skipped.
+func (e *Executor) beginAttempt(scheduledPost ScheduledPost) ([]string, bool, error) {
+	if e.store == nil {
+		return nil, true, nil
+	}
+
 	post := scheduledPost.Post
+	key := post.Identifier()
+	parts := scheduledPost.Parts()
+
+	var done []string
+	skip := false
+	err := e.store.Update(func() error {
+		if e.alreadyAttempted(scheduledPost) {
+			skip = true
+			return nil
+		}
+
+		// Resume a thread that failed partway after its last posted part
+		if record, ok := e.store.Get(key); ok {
+			if thread, ids, ok := record.PartialThread(); ok && thread == threadKey(parts) && len(ids) < len(parts) {
+				done = ids
+				logger.Info("Resuming thread %s after part %d of %d (tweet %s)", key, len(ids), len(parts), ids[len(ids)-1])
+			}
+		}
+
+		meta := state.Record{
+			Key:         key,
+			Account:     post.Account,
+			ScheduledAt: post.ScheduledAt,
+			Summary:     truncateContent(scheduledPost.Content, 50),
+		}
+		return e.store.Begin(meta, time.Now())
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record attempt for post %s: %w", key, err)
+	}
+	return done, !skip, nil
+}
+
+// Executes a single post started by beginAttempt, recording the outcome in
+// the run state
+func (e *Executor) executePost(scheduledPost ScheduledPost, done []string) error {
+	post := scheduledPost.Post
+	key := post.Identifier()
+
+	tweetIDs, err := e.publish(post, scheduledPost.Parts(), done)
+
+	if e.store != nil {
+		outcome := state.OutcomeSuccess
+		switch {
+		case err != nil:
+			outcome = state.OutcomeFailed
+		case post.DryRun:
+			outcome = state.OutcomeDryRun
+		}
+		if stateErr := e.store.Finish(key, outcome, tweetIDs, err); stateErr != nil {
+			logger.Error("Failed to record outcome for post %s: %v", key, stateErr)
+		}
+
+		// Only variants that were actually posted advance the rotation
+		if outcome == state.OutcomeSuccess && scheduledPost.Variant > 0 {
+			variant := post.Variants[scheduledPost.Variant-1]
+			if stateErr := e.store.RecordUse(RotationKey(post.Variants), VariantKey(variant), time.Now()); stateErr != nil {
+				logger.Error("Failed to record variant for post %s: %v", key, stateErr)
+			}
+		}
+
+		// Queue items count as posted only once they were
+		if outcome == state.OutcomeSuccess && post.Queue != nil {
+			if stateErr := e.store.RecordUse(QueueKey(post.Queue.Queue), post.Queue.Item, time.Now()); stateErr != nil {
+				logger.Error("Failed to record queue progress for post %s: %v", key, stateErr)
+			}
+		}
+	}
+
+	return err
+}
 
+// Posts the parts as a reply chain and returns the IDs of the created tweets
+//
+// done holds the IDs of the parts a previous attempt already posted; posting
+// continues after them. If a part fails, the IDs of the parts posted so far
+// by this call are returned with the error.
+func (e *Executor) publish(post config.Post, parts []string, done []string) ([]string, error) {
 	// Handle dry run
 	if post.DryRun {
-		logger.Info("DRY RUN: Would post: %s", post.Content)
-		fmt.Printf("✓ [DRY RUN] Would post: %s\n", post.Content)
-		return nil
+		for i := len(done); i < len(parts); i++ {
+			logger.Info("DRY RUN: Would post %s%s: %s", post.Identifier(), partLabel(i, parts), parts[i])
+			fmt.Printf("✓ [DRY RUN] Would post %s%s: %s\n", post.Identifier(), partLabel(i, parts), parts[i])
+		}
+		return nil, nil
 	}
 
-	// Handle test posts
-	if post.Test {
-		logger.Info("Test post: %s", truncateContent(post.Content, 50))
-	} else {
-		logger.Info("Posting: %s", truncateContent(post.Content, 50))
+	// Record each posted part of a thread, so that a failure or crash
+	// partway does not lose them
+	thread := ""
+	if len(parts) > 1 {
+		thread = threadKey(parts)
 	}
-
-	// Execute actual post
-	if err := poster.Post(post.Content); err != nil {
-		return fmt.Errorf("failed to post '%s': %w",
-			truncateContent(post.Content, 30), err)
+	progress := func(tweetIDs []string) {
+		if e.store == nil || thread == "" {
+			return
+		}
+		if err := e.store.Progress(post.Identifier(), thread, tweetIDs); err != nil {
+			logger.Error("Failed to record thread progress for post %s: %v", post.Identifier(), err)
+		}
 	}
+	progress(nil)
+
+	chain := append([]string(nil), done...)
+	var tweetIDs []string
+	for i := len(done); i < len(parts); i++ {
+		part := parts[i]
+		label := post.Identifier() + partLabel(i, parts)
+
+		// Handle test posts
+		if post.Test {
+			logger.Info("Test post %s: %s", label, truncateContent(part, 50))
+		} else {
+			logger.Info("Posting %s: %s", label, truncateContent(part, 50))
+		}
+
+		opts := poster.Options{Account: post.Account}
+		if i > 0 {
+			// Replies need the ID of the previous part
+			if chain[i-1] == "" {
+				return tweetIDs, fmt.Errorf("failed to post %s: tweet ID of the previous part is unknown", label)
+			}
+			opts.ReplyTo = chain[i-1]
+		}
 
-	// Success message
-	if post.Test {
-		fmt.Printf("✓ Test post successful: %s\n", truncateContent(post.Content, 50))
-	} else {
-		logger.Info("Post successful: %s", truncateContent(post.Content, 50))
+		// Execute actual post
+		tweetID, err := e.post(part, opts)
+		if err != nil {
+			return tweetIDs, fmt.Errorf("failed to post %s: %w", label, err)
+		}
+		chain = append(chain, tweetID)
+		tweetIDs = append(tweetIDs, tweetID)
+		progress(tweetIDs)
+
+		// Success message
+		if post.Test {
+			fmt.Printf("✓ Test post successful %s: %s\n", label, truncateContent(part, 50))
+		} else {
+			logger.Info("Post successful %s: %s (tweet %s)", label, truncateContent(part, 50), tweetID)
+		}
 	}
 
-	return nil
+	return tweetIDs, nil
+}
+
+// Returns the key a thread's parts are recorded under in the run state
+func threadKey(parts []string) string {
+	return hashKey(strings.Join(parts, "\x00"))
+}
+
+// Returns a label like " (part 2 of 3)" for thread parts, or nothing
+func partLabel(index int, parts []string) string {
+	if len(parts) == 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (part %d of %d)", index+1, len(parts))
 }
 
 // Returns information about scheduled posts
 func (e *Executor) GetStatus(cfg *config.Config) (map[string]interface{}, error) {
 	enabledPosts := cfg.GetEnabledPosts()
-	futurePosts := e.getFuturePosts(cfg)
+	futurePosts, suppressed := e.Plan(cfg)
 
 	status := map[string]interface{}{
 		"total_posts":   len(cfg.Posts),
 		"enabled_posts": len(enabledPosts),
 		"future_posts":  len(futurePosts),
+		"suppressed":    len(suppressed),
 		"current_time":  time.Now().Format(time.RFC3339),
 	}
 
 	if len(futurePosts) > 0 {
 		// Find next post
-		sort.Slice(futurePosts, func(i, j int) bool {
-			return futurePosts[i].ExecuteAt.Before(futurePosts[j].ExecuteAt)
-		})
 		nextPost := futurePosts[0]
+		status["next_post_id"] = nextPost.Post.Identifier()
 		status["next_post_time"] = nextPost.ExecuteAt.Format(time.RFC3339)
 		status["next_post_in"] = time.Until(nextPost.ExecuteAt).String()
 	}
@@ -0,0 +1,152 @@
+package executor
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/poster"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+// Records posted tweets and fails the parts listed in fail
+type fakePoster struct {
+	posted []string // "content>reply_to" for each tweet
+	fail   map[string]bool
+}
+
+func (f *fakePoster) post(content string, opts poster.Options) (string, error) {
+	if f.fail[content] {
+		return "", errors.New("service unavailable")
+	}
+	f.posted = append(f.posted, content+">"+opts.ReplyTo)
+	return "id-" + strconv.Itoa(len(f.posted)), nil
+}
+
+// Posts sp the way processQueue does
+func execute(t *testing.T, e *Executor, sp ScheduledPost) error {
+	t.Helper()
+	done, ok, err := e.beginAttempt(sp)
+	if err != nil {
+		t.Fatalf("beginAttempt() unexpected error = %v", err)
+	}
+	if !ok {
+		t.Fatalf("beginAttempt() skipped post %s", sp.Post.Identifier())
+	}
+	return e.executePost(sp, done)
+}
+
+func TestExecutor_ResumesPartialThread(t *testing.T) {
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	fake := &fakePoster{fail: map[string]bool{"two": true}}
+	e := NewExecutor(store)
+	e.post = fake.post
+
+	sp := ScheduledPost{
+		Post:      config.Post{ID: "thread", ScheduledAt: time.Now(), Enabled: true},
+		ExecuteAt: time.Now(),
+		Thread:    []string{"one", "two", "three"},
+	}
+
+	if err := execute(t, e, sp); err == nil {
+		t.Fatalf("executePost() expected error for failing part")
+	}
+	if e.alreadyAttempted(sp) {
+		t.Fatalf("alreadyAttempted() = true after partial failure, want retry")
+	}
+
+	delete(fake.fail, "two")
+	if err := execute(t, e, sp); err != nil {
+		t.Fatalf("executePost() unexpected error = %v", err)
+	}
+
+	// The retry continues after part one instead of posting it again
+	want := []string{"one>", "two>id-1", "three>id-2"}
+	if strings.Join(fake.posted, " ") != strings.Join(want, " ") {
+		t.Errorf("posted = %v, want %v", fake.posted, want)
+	}
+	record, _ := store.Get("thread")
+	if ids := record.TweetIDs(); strings.Join(ids, ",") != "id-1,id-2,id-3" {
+		t.Errorf("TweetIDs() = %v, want [id-1 id-2 id-3]", ids)
+	}
+	if !e.alreadyAttempted(sp) {
+		t.Errorf("alreadyAttempted() = false after thread was completed")
+	}
+}
+
+func TestExecutor_SkipsChangedPartialThread(t *testing.T) {
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	fake := &fakePoster{fail: map[string]bool{"two": true}}
+	e := NewExecutor(store)
+	e.post = fake.post
+
+	post := config.Post{ID: "thread", ScheduledAt: time.Now(), Enabled: true, Test: true}
+	if err := execute(t, e, ScheduledPost{Post: post, Thread: []string{"one", "two"}}); err == nil {
+		t.Fatalf("executePost() expected error for failing part")
+	}
+
+	// Part one is out, so the edited thread is neither resumed nor reposted
+	if !e.alreadyAttempted(ScheduledPost{Post: post, Thread: []string{"one", "two (fixed)"}}) {
+		t.Errorf("alreadyAttempted() = false for changed partial thread, want skip")
+	}
+}
+
+func TestExecutor_OverlappingRunsPostOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	sp := ScheduledPost{
+		Post:      config.Post{ID: "hello", ScheduledAt: time.Now(), Enabled: true},
+		ExecuteAt: time.Now(),
+		Content:   "hello",
+	}
+
+	// Both runs open the state before either posts
+	var executors []*Executor
+	fake := &fakePoster{}
+	for i := 0; i < 2; i++ {
+		store, err := state.Open(path)
+		if err != nil {
+			t.Fatalf("Open() unexpected error = %v", err)
+		}
+		e := NewExecutor(store)
+		e.post = fake.post
+		executors = append(executors, e)
+	}
+
+	if err := execute(t, executors[0], sp); err != nil {
+		t.Fatalf("executePost() unexpected error = %v", err)
+	}
+	if _, ok, err := executors[1].beginAttempt(sp); err != nil || ok {
+		t.Errorf("beginAttempt() = %v, %v on second run, want skip", ok, err)
+	}
+	if len(fake.posted) != 1 {
+		t.Errorf("posted = %v, want one tweet", fake.posted)
+	}
+}
+
+func TestExecutor_PlanSuppressesLintErrors(t *testing.T) {
+	cfg := &config.Config{
+		Lint: &config.Lint{Rules: []config.LintRule{{Pattern: `(?i)\bcheap\b`, Severity: config.SeverityError}}},
+		Posts: []config.Post{
+			{ID: "sale", Content: "Cheap seats!", ScheduledAt: time.Now(), Enabled: true, Test: true},
+			{ID: "fine", Content: "Tickets on sale", ScheduledAt: time.Now(), Enabled: true, Test: true},
+		},
+	}
+
+	planned, suppressed := NewExecutor(nil).Plan(cfg)
+	if len(planned) != 1 || planned[0].Post.ID != "fine" {
+		t.Errorf("Plan() planned %v, want only fine", planned)
+	}
+	if len(suppressed) != 1 || suppressed[0].Post.ID != "sale" || !strings.HasPrefix(suppressed[0].Reason, "lint: banned") {
+		t.Errorf("Plan() suppressed %v, want sale for lint", suppressed)
+	}
+}
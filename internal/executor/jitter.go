@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"time"
+)
+
+// Returns the deterministic seed for a post's jitter on the given day
+func JitterSeed(postID string, day time.Time) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(postID + "@" + day.Format("2006-01-02")))
+	return int64(h.Sum64() >> 1)
+}
+
+// Shifts the time by a seeded random offset within [-jitter, +jitter],
+// clamped to the calendar day of the original time
+func ApplyJitter(postTime time.Time, jitter time.Duration, seed int64) (time.Time, time.Duration) {
+	if jitter <= 0 {
+		return postTime, 0
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	offset := (time.Duration(r.Int63n(int64(2*jitter)+1)) - jitter).Truncate(time.Second)
+	shifted := postTime.Add(offset)
+
+	// Keep the post within the day it was scheduled for
+	dayStart := time.Date(postTime.Year(), postTime.Month(), postTime.Day(), 0, 0, 0, 0, postTime.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1).Add(-time.Second)
+	if shifted.Before(dayStart) {
+		shifted = dayStart
+	}
+	if shifted.After(dayEnd) {
+		shifted = dayEnd
+	}
+
+	return shifted, shifted.Sub(postTime)
+}
+
+// Formats a time shift with an explicit sign
+func FormatOffset(offset time.Duration) string {
+	if offset < 0 {
+		return offset.String()
+	}
+	return "+" + offset.String()
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+)
+
+func TestJitterSeed(t *testing.T) {
+	day := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	if JitterSeed("post", day) != JitterSeed("post", day.Add(3*time.Hour)) {
+		t.Errorf("JitterSeed() should be stable within a day")
+	}
+	if JitterSeed("post", day) == JitterSeed("post", day.AddDate(0, 0, 1)) {
+		t.Errorf("JitterSeed() should change between days")
+	}
+	if JitterSeed("post", day) == JitterSeed("other", day) {
+		t.Errorf("JitterSeed() should differ between posts")
+	}
+}
+
+func TestApplyJitter(t *testing.T) {
+	postTime := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("zero jitter keeps the time", func(t *testing.T) {
+		got, offset := ApplyJitter(postTime, 0, 42)
+		if !got.Equal(postTime) || offset != 0 {
+			t.Errorf("ApplyJitter() = %v, %v, want %v, 0", got, offset, postTime)
+		}
+	})
+
+	t.Run("offset stays within the jitter range", func(t *testing.T) {
+		jitter := 10 * time.Minute
+		for seed := int64(0); seed < 200; seed++ {
+			got, offset := ApplyJitter(postTime, jitter, seed)
+			if offset < -jitter || offset > jitter {
+				t.Fatalf("ApplyJitter() offset = %v, want within ±%v", offset, jitter)
+			}
+			if !got.Equal(postTime.Add(offset)) {
+				t.Fatalf("ApplyJitter() time = %v, want %v", got, postTime.Add(offset))
+			}
+		}
+	})
+
+	t.Run("same seed gives the same time", func(t *testing.T) {
+		first, _ := ApplyJitter(postTime, time.Hour, 7)
+		second, _ := ApplyJitter(postTime, time.Hour, 7)
+		if !first.Equal(second) {
+			t.Errorf("ApplyJitter() = %v and %v, want identical results", first, second)
+		}
+	})
+
+	t.Run("shifted time stays within the day", func(t *testing.T) {
+		late := time.Date(2024, 6, 1, 23, 50, 0, 0, time.UTC)
+		dayEnd := time.Date(2024, 6, 1, 23, 59, 59, 0, time.UTC)
+		for seed := int64(0); seed < 200; seed++ {
+			got, _ := ApplyJitter(late, 2*time.Hour, seed)
+			if got.After(dayEnd) {
+				t.Fatalf("ApplyJitter() = %v, want no later than %v", got, dayEnd)
+			}
+		}
+	})
+}
+
+func TestExecutor_PlanJitterNearNow(t *testing.T) {
+	now := time.Now()
+	scheduledAt := now.Add(3 * time.Minute)
+	if !IsToday(scheduledAt.Add(30*time.Minute), now) {
+		t.Skip("jittered posts could fall on tomorrow")
+	}
+
+	cfg := &config.Config{Jitter: 30 * time.Minute}
+	for i := 1; i <= 8; i++ {
+		cfg.Posts = append(cfg.Posts, config.Post{
+			ID:          fmt.Sprintf("p%d", i),
+			Content:     fmt.Sprintf("Post %d", i),
+			ScheduledAt: scheduledAt,
+			Enabled:     true,
+		})
+	}
+
+	planned, suppressed := NewExecutor(nil).Plan(cfg)
+	if len(planned) != 8 || len(suppressed) != 0 {
+		t.Fatalf("Plan() planned %d and suppressed %d posts, want 8 and 0", len(planned), len(suppressed))
+	}
+	for _, scheduledPost := range planned {
+		if scheduledPost.ExecuteAt.Before(now) {
+			t.Errorf("post %s executes at %v, before now (%v)", scheduledPost.Post.ID, scheduledPost.ExecuteAt, now)
+		}
+	}
+}
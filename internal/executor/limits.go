@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+)
+
+// Represents a post that will not be executed in this run
+type SuppressedPost struct {
+	Post   config.Post
+	At     time.Time // Time the post was planned for
+	Reason string
+}
+
+// Enforces posting caps per account, counting posts already made as given
+// by posted. Posts over an hourly cap are deferred to the next hour with
+// capacity or rejected according to overflow; posts over the daily cap
+// are rejected. Posts after a deferred post on the same account are pushed
+// back to keep the gap given by gapFor (nil for none). Returns the kept
+// posts sorted by execution time.
+func ApplyLimits(posts []ScheduledPost, limitFor func(account string) config.AccountLimit,
+	overflow config.OverflowPolicy, posted map[string][]time.Time,
+	gapFor func(account string) time.Duration) ([]ScheduledPost, []SuppressedPost) {
+	sorted := make([]ScheduledPost, len(posts))
+	copy(sorted, posts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ExecuteAt.Before(sorted[j].ExecuteAt)
+	})
+
+	times := make(map[string][]time.Time)
+	for account, postedTimes := range posted {
+		times[account] = append(times[account], postedTimes...)
+	}
+
+	var kept []ScheduledPost
+	var rejected []SuppressedPost
+	last := make(map[string]ScheduledPost) // Previous kept post per account
+
+	for _, scheduledPost := range sorted {
+		// Test posts are executed immediately and are not capped
+		if scheduledPost.Post.Test {
+			kept = append(kept, scheduledPost)
+			continue
+		}
+
+		account := scheduledPost.Post.Account
+		limit := limitFor(account)
+
+		// A deferred post may have moved up to this one
+		start := scheduledPost.ExecuteAt
+		if prev, ok := last[account]; ok && gapFor != nil {
+			if earliest := prev.ExecuteAt.Add(gapFor(account)); start.Before(earliest) {
+				scheduledPost.Adjustments = append(scheduledPost.Adjustments, fmt.Sprintf("limits: moved from %s to keep %v after deferred post %s",
+					start.Format("15:04:05"), gapFor(account), prev.Post.Identifier()))
+				start = earliest
+			}
+		}
+
+		executeAt, reason := nextAllowedTime(start, times[account], limit, overflow)
+		if reason == "" && !sameDay(executeAt, scheduledPost.ExecuteAt) {
+			reason = "deferred posts leave no slot today"
+		}
+		if reason != "" {
+			rejected = append(rejected, SuppressedPost{
+				Post:   scheduledPost.Post,
+				At:     scheduledPost.ExecuteAt,
+				Reason: fmt.Sprintf("limits: %s for account %s", reason, config.AccountName(account)),
+			})
+			continue
+		}
+
+		if !executeAt.Equal(start) {
+			scheduledPost.Adjustments = append(scheduledPost.Adjustments, fmt.Sprintf("limits: deferred from %s (hourly limit of %d reached for account %s)",
+				start.Format("15:04:05"), limit.PerHour, config.AccountName(account)))
+		}
+		scheduledPost.ExecuteAt = executeAt
+		times[account] = append(times[account], executeAt)
+		last[account] = scheduledPost
+		kept = append(kept, scheduledPost)
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].ExecuteAt.Before(kept[j].ExecuteAt)
+	})
+	return kept, rejected
+}
+
+// Returns the earliest time at or after t within t's day that respects the
+// caps, or a reason why the post cannot be executed today
+func nextAllowedTime(t time.Time, times []time.Time, limit config.AccountLimit, overflow config.OverflowPolicy) (time.Time, string) {
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	if limit.PerDay > 0 && countBetween(times, dayStart, dayEnd) >= limit.PerDay {
+		return t, fmt.Sprintf("daily limit of %d posts reached", limit.PerDay)
+	}
+	if limit.PerHour <= 0 {
+		return t, ""
+	}
+
+	for {
+		hourStart := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		if countBetween(times, hourStart, hourStart.Add(time.Hour)) < limit.PerHour {
+			return t, ""
+		}
+		if overflow == config.OverflowReject {
+			return t, fmt.Sprintf("hourly limit of %d posts reached", limit.PerHour)
+		}
+
+		t = hourStart.Add(time.Hour)
+		if !t.Before(dayEnd) {
+			return t, fmt.Sprintf("hourly limit of %d posts leaves no slot today", limit.PerHour)
+		}
+	}
+}
+
+// Reports whether a and b fall on the same calendar day
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// Counts the times within [start, end)
+func countBetween(times []time.Time, start, end time.Time) int {
+	count := 0
+	for _, t := range times {
+		if !t.Before(start) && t.Before(end) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,205 @@
+package executor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+func TestApplyLimits(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	limitFor := func(account string) config.AccountLimit {
+		return config.AccountLimit{PerHour: 2, PerDay: 4}
+	}
+
+	posts := []ScheduledPost{
+		{Post: config.Post{ID: "a"}, ExecuteAt: at(9, 0)},
+		{Post: config.Post{ID: "b"}, ExecuteAt: at(9, 10)},
+		{Post: config.Post{ID: "c"}, ExecuteAt: at(9, 20)},
+		{Post: config.Post{ID: "d"}, ExecuteAt: at(9, 30)},
+		{Post: config.Post{ID: "e"}, ExecuteAt: at(11, 0)},
+		{Post: config.Post{ID: "f"}, ExecuteAt: at(12, 0)},
+		{Post: config.Post{ID: "other", Account: "brand"}, ExecuteAt: at(9, 5)},
+		{Post: config.Post{ID: "test", Test: true}, ExecuteAt: at(9, 6)},
+	}
+
+	t.Run("defer moves hourly overflow and rejects daily overflow", func(t *testing.T) {
+		kept, rejected := ApplyLimits(posts, limitFor, config.OverflowDefer, nil, nil)
+
+		want := map[string]time.Time{
+			"a":     at(9, 0),
+			"b":     at(9, 10),
+			"c":     at(10, 0),
+			"d":     at(10, 0),
+			"other": at(9, 5),
+			"test":  at(9, 6),
+		}
+		if len(kept) != len(want) {
+			t.Fatalf("ApplyLimits() kept %d posts, want %d", len(kept), len(want))
+		}
+		for _, sp := range kept {
+			if !sp.ExecuteAt.Equal(want[sp.Post.ID]) {
+				t.Errorf("post %s ExecuteAt = %s, want %s",
+					sp.Post.ID, sp.ExecuteAt.Format("15:04"), want[sp.Post.ID].Format("15:04"))
+			}
+		}
+
+		if len(rejected) != 2 {
+			t.Fatalf("ApplyLimits() rejected %d posts, want 2", len(rejected))
+		}
+		for _, r := range rejected {
+			if !strings.Contains(r.Reason, "daily limit of 4") {
+				t.Errorf("rejected post %s reason = %q, want daily limit", r.Post.ID, r.Reason)
+			}
+		}
+	})
+
+	t.Run("reject drops hourly overflow", func(t *testing.T) {
+		_, rejected := ApplyLimits(posts, limitFor, config.OverflowReject, nil, nil)
+
+		ids := make([]string, 0, len(rejected))
+		for _, r := range rejected {
+			ids = append(ids, r.Post.ID)
+		}
+		if strings.Join(ids, ",") != "c,d" {
+			t.Errorf("ApplyLimits() rejected %v, want [c d]", ids)
+		}
+	})
+
+	t.Run("posts already made count against the caps", func(t *testing.T) {
+		posted := map[string][]time.Time{"": {at(8, 0), at(8, 30), at(9, 1)}}
+		kept, rejected := ApplyLimits(posts[:2], limitFor, config.OverflowDefer, posted, nil)
+
+		if len(kept) != 1 || kept[0].Post.ID != "a" || !kept[0].ExecuteAt.Equal(at(9, 0)) {
+			t.Errorf("ApplyLimits() kept %v, want only a at 09:00", kept)
+		}
+		if len(rejected) != 1 || rejected[0].Post.ID != "b" {
+			t.Errorf("ApplyLimits() rejected %v, want only b", rejected)
+		}
+	})
+}
+
+func TestExecutor_PlanLimitsAfterSpacing(t *testing.T) {
+	now := time.Now()
+	hour := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location()).Add(time.Hour)
+	if !IsToday(hour.Add(3*time.Hour), now) {
+		t.Skip("test posts would fall on tomorrow")
+	}
+	at := func(min int) time.Time {
+		return hour.Add(time.Duration(min) * time.Minute)
+	}
+
+	// Spacing moves b into the next hour, which a and the cap would
+	// otherwise leave full with c and d
+	cfg := &config.Config{
+		Spacing: &config.Spacing{MinGap: 10 * time.Minute, Policy: config.SpacingShift},
+		Limits:  &config.Limits{PerHour: 2},
+		Posts: []config.Post{
+			{ID: "a", Content: "a", ScheduledAt: at(50), Enabled: true},
+			{ID: "b", Content: "b", ScheduledAt: at(55), Enabled: true},
+			{ID: "c", Content: "c", ScheduledAt: at(70), Enabled: true},
+			{ID: "d", Content: "d", ScheduledAt: at(100), Enabled: true},
+		},
+	}
+
+	planned, suppressed := NewExecutor(nil).Plan(cfg)
+	if len(suppressed) != 0 {
+		t.Errorf("Plan() suppressed %v, want none", suppressed)
+	}
+	want := map[string]time.Time{"a": at(50), "b": at(60), "c": at(70), "d": at(120)}
+	perHour := make(map[int]int)
+	for _, sp := range planned {
+		if !sp.ExecuteAt.Equal(want[sp.Post.ID]) {
+			t.Errorf("post %s ExecuteAt = %s, want %s",
+				sp.Post.ID, sp.ExecuteAt.Format("15:04"), want[sp.Post.ID].Format("15:04"))
+		}
+		perHour[sp.ExecuteAt.Hour()]++
+	}
+	for h, count := range perHour {
+		if count > 2 {
+			t.Errorf("%d posts planned in hour %d, want at most 2", count, h)
+		}
+	}
+}
+
+func TestExecutor_PlanSameDayRerun(t *testing.T) {
+	now := time.Now()
+	if !IsToday(now.Add(3*time.Minute), now) {
+		t.Skip("test posts would fall on tomorrow")
+	}
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+
+	// An earlier run posted early and left pending with an unknown outcome
+	cfg := &config.Config{
+		Limits: &config.Limits{PerDay: 2},
+		Posts: []config.Post{
+			{ID: "early", Content: "early", ScheduledAt: now.Add(time.Minute), Enabled: true},
+			{ID: "pending", Content: "pending", ScheduledAt: now.Add(2 * time.Minute), Enabled: true},
+			{ID: "late", Content: "late", ScheduledAt: now.Add(3 * time.Minute), Enabled: true},
+		},
+	}
+	for _, id := range []string{"early", "pending"} {
+		if err := store.Begin(state.Record{Key: id}, now); err != nil {
+			t.Fatalf("Begin() unexpected error = %v", err)
+		}
+	}
+	if err := store.Finish("early", state.OutcomeSuccess, []string{"1"}, nil); err != nil {
+		t.Fatalf("Finish() unexpected error = %v", err)
+	}
+
+	// The post made counts once against the cap, leaving room for late
+	planned, suppressed := NewExecutor(store).Plan(cfg)
+	var got []string
+	for _, sp := range planned {
+		got = append(got, sp.Post.ID)
+	}
+	if fmt.Sprint(got) != "[late]" {
+		t.Errorf("Plan() planned %v, want [late]", got)
+	}
+	if len(suppressed) != 0 {
+		t.Errorf("Plan() suppressed %v, want none", suppressed)
+	}
+}
+
+func TestApplyLimits_KeepsGapAfterDeferredPost(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	limitFor := func(account string) config.AccountLimit {
+		return config.AccountLimit{PerHour: 1}
+	}
+	gapFor := func(account string) time.Duration { return 10 * time.Minute }
+
+	posts := []ScheduledPost{
+		{Post: config.Post{ID: "a"}, ExecuteAt: at(9, 0)},
+		{Post: config.Post{ID: "b"}, ExecuteAt: at(9, 30)},
+		{Post: config.Post{ID: "c"}, ExecuteAt: at(10, 5)},
+		{Post: config.Post{ID: "late"}, ExecuteAt: at(23, 30)},
+		{Post: config.Post{ID: "later"}, ExecuteAt: at(23, 45)},
+	}
+	kept, rejected := ApplyLimits(posts, limitFor, config.OverflowDefer, nil, gapFor)
+
+	want := map[string]time.Time{"a": at(9, 0), "b": at(10, 0), "c": at(11, 0), "late": at(23, 30)}
+	if len(kept) != len(want) {
+		t.Fatalf("ApplyLimits() kept %d posts, want %d", len(kept), len(want))
+	}
+	for _, sp := range kept {
+		if !sp.ExecuteAt.Equal(want[sp.Post.ID]) {
+			t.Errorf("post %s ExecuteAt = %s, want %s",
+				sp.Post.ID, sp.ExecuteAt.Format("15:04"), want[sp.Post.ID].Format("15:04"))
+		}
+	}
+	if len(rejected) != 1 || rejected[0].Post.ID != "later" {
+		t.Errorf("ApplyLimits() rejected %v, want only later", rejected)
+	}
+}
@@ -23,6 +23,23 @@ func IsToday(postTime, currentTime time.Time) bool {
 	return postTime.After(today) && postTime.Before(tomorrow)
 }
 
+// Checks if a post scheduled in the past should be posted now under the missed policy
+func ShouldCatchUp(postTime, currentTime time.Time, policy config.MissedPolicy) bool {
+	if postTime.After(currentTime) {
+		return false
+	}
+
+	switch policy.Action {
+	case config.MissedPostNow:
+		// Limited to today so that posts kept as history are never posted
+		return IsToday(postTime, currentTime)
+	case config.MissedPostIfWithin:
+		return currentTime.Sub(postTime) <= policy.Within
+	default:
+		return false
+	}
+}
+
 // Filters posts to include only future posts for today
 func FilterFuturePosts(posts []config.Post, currentTime time.Time) []config.Post {
 	var futurePosts []config.Post
@@ -186,3 +186,66 @@ func TestSortByExecuteTime(t *testing.T) {
 		}
 	}
 }
+
+func TestShouldCatchUp(t *testing.T) {
+	currentTime := time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		postTime time.Time
+		policy   config.MissedPolicy
+		want     bool
+	}{
+		{
+			name:     "skip policy never catches up",
+			postTime: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC),
+			policy:   config.MissedPolicy{Action: config.MissedSkip},
+			want:     false,
+		},
+		{
+			name:     "post_now catches up earlier today",
+			postTime: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC),
+			policy:   config.MissedPolicy{Action: config.MissedPostNow},
+			want:     true,
+		},
+		{
+			name:     "post_now ignores previous days",
+			postTime: time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC),
+			policy:   config.MissedPolicy{Action: config.MissedPostNow},
+			want:     false,
+		},
+		{
+			name:     "post_if_within catches up inside the window",
+			postTime: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC),
+			policy:   config.MissedPolicy{Action: config.MissedPostIfWithin, Within: 2 * time.Hour},
+			want:     true,
+		},
+		{
+			name:     "post_if_within crosses midnight",
+			postTime: time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC),
+			policy:   config.MissedPolicy{Action: config.MissedPostIfWithin, Within: 12 * time.Hour},
+			want:     true,
+		},
+		{
+			name:     "post_if_within skips outside the window",
+			postTime: time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC),
+			policy:   config.MissedPolicy{Action: config.MissedPostIfWithin, Within: 2 * time.Hour},
+			want:     false,
+		},
+		{
+			name:     "future posts are not missed",
+			postTime: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+			policy:   config.MissedPolicy{Action: config.MissedPostNow},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ShouldCatchUp(tt.postTime, currentTime, tt.policy)
+			if got != tt.want {
+				t.Errorf("ShouldCatchUp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zinrai/x-scheduler/pkg/logger"
+)
+
+// Interval at which a held queue checks whether posting was resumed
+var pausePollInterval = 5 * time.Second
+
+// Pauses and resumes posting from signals, a sentinel file or code
+//
+// SIGUSR1 pauses and SIGUSR2 resumes. While the sentinel file exists,
+// posting is paused; its content is logged as the reason.
+type PauseController struct {
+	mu       sync.Mutex
+	paused   bool
+	byFile   bool   // Current pause was triggered by the sentinel file
+	source   string // What triggered the current state
+	sentinel string
+}
+
+// Creates a pause controller watching the given sentinel file (empty disables it)
+func NewPauseController(sentinel string) *PauseController {
+	return &PauseController{sentinel: sentinel}
+}
+
+// Pauses posting, recording what triggered it
+func (p *PauseController) Pause(source string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pauseLocked(source, false)
+}
+
+// Resumes posting, recording what triggered it
+func (p *PauseController) Resume(source string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resumeLocked(source)
+}
+
+// Reports whether posting is paused and what triggered the pause
+func (p *PauseController) Paused() (bool, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checkSentinelLocked()
+	return p.paused, p.source
+}
+
+// Pauses on SIGUSR1 and resumes on SIGUSR2 until the returned function is called
+func (p *PauseController) WatchSignals() func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				source := fmt.Sprintf("signal %s", signalName(sig))
+				if sig == syscall.SIGUSR1 {
+					p.Pause(source)
+				} else {
+					p.Resume(source)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// Blocks while posting is paused, returning false if still paused at until
+func (p *PauseController) WaitWhilePaused(poll time.Duration, until time.Time) bool {
+	for {
+		paused, _ := p.Paused()
+		if !paused {
+			return true
+		}
+
+		remaining := time.Until(until)
+		if remaining <= 0 {
+			return false
+		}
+		time.Sleep(min(poll, remaining))
+	}
+}
+
+func (p *PauseController) pauseLocked(source string, byFile bool) {
+	if p.paused {
+		return
+	}
+	p.paused = true
+	p.byFile = byFile
+	p.source = source
+	logger.Warn("Posting paused by %s", source)
+}
+
+func (p *PauseController) resumeLocked(source string) {
+	if !p.paused {
+		return
+	}
+	logger.Info("Posting resumed by %s (was paused by %s)", source, p.source)
+	p.paused = false
+	p.byFile = false
+	p.source = source
+}
+
+// Pauses while the sentinel file exists and resumes when a file-triggered
+// pause has its file removed
+func (p *PauseController) checkSentinelLocked() {
+	if p.sentinel == "" {
+		return
+	}
+
+	info, err := os.Stat(p.sentinel)
+	switch {
+	case err == nil:
+		if !p.paused {
+			p.pauseLocked(describeSentinel(p.sentinel, info), true)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if p.paused && p.byFile {
+			p.resumeLocked(fmt.Sprintf("removal of sentinel file %s", p.sentinel))
+		}
+	default:
+		logger.Warn("Failed to check pause sentinel file %s: %v", p.sentinel, err)
+	}
+}
+
+// Describes who created the sentinel file and why, from its owner and content
+func describeSentinel(path string, info os.FileInfo) string {
+	description := fmt.Sprintf("sentinel file %s", path)
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		owner := strconv.FormatUint(uint64(stat.Uid), 10)
+		if u, err := user.LookupId(owner); err == nil {
+			owner = u.Username
+		}
+		description += fmt.Sprintf(" owned by %s", owner)
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if reason := strings.TrimSpace(string(data)); reason != "" {
+			description += fmt.Sprintf(": %s", truncateContent(reason, 100))
+		}
+	}
+
+	return description
+}
+
+// Returns the conventional name of a signal
+func signalName(sig os.Signal) string {
+	switch sig {
+	case syscall.SIGUSR1:
+		return "SIGUSR1"
+	case syscall.SIGUSR2:
+		return "SIGUSR2"
+	}
+	return sig.String()
+}
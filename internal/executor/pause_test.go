@@ -0,0 +1,261 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+func TestPauseController_Sentinel(t *testing.T) {
+	sentinel := filepath.Join(t.TempDir(), "pause")
+	p := NewPauseController(sentinel)
+
+	if paused, _ := p.Paused(); paused {
+		t.Fatalf("Paused() = true without sentinel file")
+	}
+
+	if err := os.WriteFile(sentinel, []byte("alice: incident 42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	paused, source := p.Paused()
+	if !paused {
+		t.Fatalf("Paused() = false with sentinel file")
+	}
+	if !strings.Contains(source, sentinel) || !strings.Contains(source, "alice: incident 42") {
+		t.Errorf("Paused() source = %q, want sentinel path and reason", source)
+	}
+
+	if err := os.Remove(sentinel); err != nil {
+		t.Fatal(err)
+	}
+	if paused, _ := p.Paused(); paused {
+		t.Errorf("Paused() = true after sentinel file was removed")
+	}
+}
+
+func TestPauseController_ManualPauseSurvivesMissingSentinel(t *testing.T) {
+	p := NewPauseController(filepath.Join(t.TempDir(), "pause"))
+
+	p.Pause("test")
+	if paused, source := p.Paused(); !paused || source != "test" {
+		t.Errorf("Paused() = %v, %q, want true, 'test'", paused, source)
+	}
+
+	p.Resume("test")
+	if paused, _ := p.Paused(); paused {
+		t.Errorf("Paused() = true after Resume()")
+	}
+}
+
+func TestPauseController_Signals(t *testing.T) {
+	p := NewPauseController("")
+	stop := p.WatchSignals()
+	defer stop()
+
+	waitFor := func(want bool) (string, bool) {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if paused, source := p.Paused(); paused == want {
+				return source, true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return "", false
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	source, ok := waitFor(true)
+	if !ok {
+		t.Fatalf("SIGUSR1 did not pause posting")
+	}
+	if source != "signal SIGUSR1" {
+		t.Errorf("pause source = %q, want 'signal SIGUSR1'", source)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := waitFor(false); !ok {
+		t.Errorf("SIGUSR2 did not resume posting")
+	}
+}
+
+func TestExecutor_AwaitResume(t *testing.T) {
+	post := ScheduledPost{Post: config.Post{ID: "a"}, ExecuteAt: time.Now()}
+
+	t.Run("drop mode drops posts while paused", func(t *testing.T) {
+		e := NewExecutor(nil)
+		e.pause = NewPauseController("")
+		e.pauseMode = config.PauseDrop
+
+		if held, ok := e.awaitResume(post); held || !ok {
+			t.Errorf("awaitResume() = %v, %v while not paused, want false, true", held, ok)
+		}
+		e.pause.Pause("test")
+		if _, ok := e.awaitResume(post); ok {
+			t.Errorf("awaitResume() ok = true while paused in drop mode")
+		}
+	})
+
+	t.Run("hold mode waits until resumed", func(t *testing.T) {
+		defer func(interval time.Duration) { pausePollInterval = interval }(pausePollInterval)
+		pausePollInterval = 10 * time.Millisecond
+
+		e := NewExecutor(nil)
+		e.pause = NewPauseController("")
+		e.pauseMode = config.PauseHold
+		e.pause.Pause("test")
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			e.pause.Resume("test")
+		}()
+
+		done := make(chan bool)
+		go func() {
+			held, ok := e.awaitResume(post)
+			done <- held && ok
+		}()
+		select {
+		case ok := <-done:
+			if !ok {
+				t.Errorf("awaitResume() did not report a held post that may be posted")
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("awaitResume() did not return after resume")
+		}
+	})
+
+	t.Run("hold mode gives up at the end of the day", func(t *testing.T) {
+		e := NewExecutor(nil)
+		e.pause = NewPauseController("")
+		e.pauseMode = config.PauseHold
+		e.pause.Pause("test")
+
+		// The post's day has already ended, so the hold must not wait
+		yesterday := ScheduledPost{Post: config.Post{ID: "a"}, ExecuteAt: time.Now().AddDate(0, 0, -1)}
+		if held, ok := e.awaitResume(yesterday); !held || ok {
+			t.Errorf("awaitResume() = %v, %v after the end of the day, want true, false", held, ok)
+		}
+	})
+}
+
+func TestPauseController_WaitWhilePausedDeadline(t *testing.T) {
+	p := NewPauseController("")
+	p.Pause("test")
+
+	start := time.Now()
+	if p.WaitWhilePaused(time.Hour, start.Add(20*time.Millisecond)) {
+		t.Errorf("WaitWhilePaused() = true while still paused at the deadline")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitWhilePaused() returned after %v, want it to stop at the deadline", elapsed)
+	}
+
+	p.Resume("test")
+	if !p.WaitWhilePaused(time.Hour, start) {
+		t.Errorf("WaitWhilePaused() = false while not paused")
+	}
+}
+
+func TestExecutor_RecheckAfterHold(t *testing.T) {
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	// Another post went out at 10:00 while the post planned for 9:00 was held
+	newStore := func(t *testing.T) *state.Store {
+		store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+		if err != nil {
+			t.Fatalf("Open() unexpected error = %v", err)
+		}
+		if err := store.Begin(state.Record{Key: "other", ScheduledAt: at(10, 0)}, at(10, 0)); err != nil {
+			t.Fatalf("Begin() unexpected error = %v", err)
+		}
+		if err := store.Finish("other", state.OutcomeSuccess, []string{"1"}, nil); err != nil {
+			t.Fatalf("Finish() unexpected error = %v", err)
+		}
+		return store
+	}
+
+	tests := []struct {
+		name       string
+		cfg        *config.Config
+		resumed    time.Time
+		wantAt     time.Time
+		wantReason string
+	}{
+		{
+			name:    "no checks apply",
+			cfg:     &config.Config{},
+			resumed: at(10, 5),
+			wantAt:  at(10, 5),
+		},
+		{
+			name:    "spacing shift moves the post after the last post",
+			cfg:     &config.Config{Spacing: &config.Spacing{MinGap: 30 * time.Minute, Policy: config.SpacingShift}},
+			resumed: at(10, 5),
+			wantAt:  at(10, 30),
+		},
+		{
+			name:       "spacing fail drops the post",
+			cfg:        &config.Config{Spacing: &config.Spacing{MinGap: 30 * time.Minute}},
+			resumed:    at(10, 5),
+			wantReason: "spacing: 5m0s after the last post",
+		},
+		{
+			name:       "daily limit counts posts made during the hold",
+			cfg:        &config.Config{Limits: &config.Limits{PerDay: 1}},
+			resumed:    at(10, 5),
+			wantReason: "daily limit of 1 posts reached",
+		},
+		{
+			name:    "hourly limit defers the post",
+			cfg:     &config.Config{Limits: &config.Limits{PerHour: 1}},
+			resumed: at(10, 5),
+			wantAt:  at(11, 0),
+		},
+		{
+			name:       "blackout entered during the hold drops the post",
+			cfg:        &config.Config{Blackouts: []config.Blackout{{Name: "incident", From: "2024-06-01T10:00:00Z", To: "2024-06-01T12:00:00Z"}}},
+			resumed:    at(10, 5),
+			wantReason: "incident",
+		},
+		{
+			name:       "shift past the end of the day drops the post",
+			cfg:        &config.Config{Spacing: &config.Spacing{MinGap: 14 * time.Hour, Policy: config.SpacingShift}},
+			resumed:    at(10, 5),
+			wantReason: "no slot left today",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExecutor(newStore(t))
+			sp := ScheduledPost{Post: config.Post{ID: "held", Enabled: true}, ExecuteAt: at(9, 0)}
+
+			got, reason := e.recheckAfterHold(tt.cfg, sp, tt.resumed)
+			if tt.wantReason != "" {
+				if !strings.Contains(reason, tt.wantReason) {
+					t.Errorf("recheckAfterHold() reason = %q, want it to contain %q", reason, tt.wantReason)
+				}
+				return
+			}
+			if reason != "" {
+				t.Fatalf("recheckAfterHold() unexpected reason = %q", reason)
+			}
+			if !got.ExecuteAt.Equal(tt.wantAt) {
+				t.Errorf("recheckAfterHold() ExecuteAt = %v, want %v", got.ExecuteAt, tt.wantAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/state"
+	"github.com/zinrai/x-scheduler/pkg/logger"
+)
+
+// Returns the key of a queue's progress in the run state
+func QueueKey(name string) string {
+	return "queue:" + name
+}
+
+// Returns the index of the item to post next, or false if the queue is empty
+//
+// Items never posted come first, in configuration or shuffled order. Once
+// all were posted, a recycling queue takes the item posted longest ago if
+// its cooldown has passed at the given time.
+func NextQueueItem(queue config.Queue, history state.Rotation, at time.Time) (int, bool) {
+	order := queueOrder(queue)
+	for _, i := range order {
+		if _, posted := history.LastUsed[queue.Items[i].Key()]; !posted {
+			return i, true
+		}
+	}
+	if queue.WhenEmptyOrDefault() != config.QueueRecycle {
+		return 0, false
+	}
+
+	best := -1
+	var bestAt time.Time
+	for _, i := range order {
+		last := history.LastUsed[queue.Items[i].Key()]
+		if at.Sub(last) < queue.Cooldown {
+			continue
+		}
+		if best < 0 || last.Before(bestAt) {
+			best, bestAt = i, last
+		}
+	}
+	return best, best >= 0
+}
+
+// Returns the number of items in the queue that were never posted
+func RemainingQueueItems(queue config.Queue, history state.Rotation) int {
+	remaining := 0
+	for _, item := range queue.Items {
+		if _, posted := history.LastUsed[item.Key()]; !posted {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// Returns the indexes of the queue's items in posting order
+func queueOrder(queue config.Queue) []int {
+	order := make([]int, len(queue.Items))
+	for i := range order {
+		order[i] = i
+	}
+	if queue.OrderOrDefault() == config.QueueShuffled {
+		// Seeding with the name keeps the order stable between runs
+		h := fnv.New64a()
+		h.Write([]byte(queue.Name))
+		r := rand.New(rand.NewSource(int64(h.Sum64() >> 1)))
+		r.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	return order
+}
+
+// Returns posts for the queue's slots from the start of today through the
+// given number of days, each filled with the item it would take if every
+// earlier slot is posted
+//
+// Slots recorded in the run state are done and take no item. Slots that
+// have passed take an item only if the missed policy catches them up, so
+// that items are not used up by slots that will never post.
+func QueuePosts(cfg *config.Config, queue config.Queue, store *state.Store, now time.Time, days int) []config.Post {
+	var history state.Rotation
+	if store != nil {
+		history = store.Rotation(QueueKey(queue.Name))
+	}
+
+	var posts []config.Post
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for d := 0; d < days; d++ {
+		for _, at := range queue.SlotsOn(today.AddDate(0, 0, d)) {
+			slot := queue.Post(config.QueueItem{}, at)
+			if store != nil {
+				if _, ok := store.Get(slot.ID); ok {
+					logger.Debug("Queue %s slot at %s was already attempted", queue.Name, at.Format("15:04"))
+					continue
+				}
+			}
+			if !at.After(now) && !ShouldCatchUp(at, now, cfg.MissedPolicyFor(slot)) {
+				logger.Debug("Queue %s slot at %s was missed", queue.Name, at.Format("15:04"))
+				continue
+			}
+
+			index, ok := NextQueueItem(queue, history, at)
+			if !ok {
+				// Only today's slots are due; later ones may still be refilled
+				if d == 0 {
+					logger.Warn("Queue %s has no items left for the slot at %s", queue.Name, at.Format("15:04"))
+				}
+				continue
+			}
+			item := queue.Items[index]
+			history.Use(item.Key(), at)
+			posts = append(posts, queue.Post(item, at))
+		}
+	}
+	return posts
+}
+
+// Returns posts for today's slots of the enabled queues, filled with the next items
+func (e *Executor) queuedPosts(cfg *config.Config, now time.Time) []config.Post {
+	var posts []config.Post
+	for _, queue := range cfg.Queues {
+		if queue.Enabled {
+			posts = append(posts, QueuePosts(cfg, queue, e.store, now, 1)...)
+		}
+	}
+	return posts
+}
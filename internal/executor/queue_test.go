@@ -0,0 +1,190 @@
+package executor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+func TestNextQueueItem(t *testing.T) {
+	items := []config.QueueItem{{ID: "a", Content: "A"}, {ID: "b", Content: "B"}, {ID: "c", Content: "C"}}
+	at := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+	posted := func(ago ...time.Duration) state.Rotation {
+		rotation := state.Rotation{LastUsed: make(map[string]time.Time)}
+		for i, d := range ago {
+			rotation.LastUsed[items[i].ID] = at.Add(-d)
+		}
+		return rotation
+	}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		queue   config.Queue
+		history state.Rotation
+		want    int
+		wantOK  bool
+	}{
+		{
+			name:    "first unposted item",
+			queue:   config.Queue{Items: items},
+			history: posted(day),
+			want:    1,
+			wantOK:  true,
+		},
+		{
+			name:    "empty queue warns",
+			queue:   config.Queue{Items: items},
+			history: posted(3*day, 2*day, day),
+			wantOK:  false,
+		},
+		{
+			name:    "recycles the item posted longest ago",
+			queue:   config.Queue{Items: items, WhenEmpty: config.QueueRecycle},
+			history: posted(2*day, 3*day, day),
+			want:    1,
+			wantOK:  true,
+		},
+		{
+			name:    "recycling waits for the cooldown",
+			queue:   config.Queue{Items: items, WhenEmpty: config.QueueRecycle, Cooldown: 4 * day},
+			history: posted(2*day, 3*day, day),
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NextQueueItem(tt.queue, tt.history, at)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("NextQueueItem() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	// Shuffled queues visit every item once, in the same order on every run
+	queue := config.Queue{Name: "tips", Order: config.QueueShuffled}
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		queue.Items = append(queue.Items, config.QueueItem{ID: id, Content: id})
+	}
+	var first []int
+	history := state.Rotation{}
+	for range queue.Items {
+		index, ok := NextQueueItem(queue, history, at)
+		if !ok {
+			t.Fatalf("NextQueueItem() ran dry after %d items", len(first))
+		}
+		history.Use(queue.Items[index].Key(), at)
+		first = append(first, index)
+	}
+	if again := queueOrder(queue); !equalInts(again, first) {
+		t.Errorf("shuffled order changed between runs: %v, then %v", first, again)
+	}
+	if equalInts(first, []int{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("shuffled order = %v, want a shuffled order", first)
+	}
+	if RemainingQueueItems(queue, history) != 0 {
+		t.Errorf("RemainingQueueItems() = %d, want 0", RemainingQueueItems(queue, history))
+	}
+}
+
+func TestExecutor_QueuedPosts(t *testing.T) {
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := config.Queue{
+		Name:    "tips",
+		Enabled: true,
+		Slots: []config.QueueSlot{
+			{At: "15:00"},
+			{At: "10:00", Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}},
+			{At: "12:00", Weekdays: []string{"sat"}},
+		},
+		Items: []config.QueueItem{{ID: "a", Content: "Tip A"}, {ID: "b", Content: "Tip B"}, {ID: "c", Content: "Tip C"}},
+	}
+	monday := time.Date(2024, 6, 10, 8, 0, 0, 0, time.UTC)
+
+	// Item a was posted in the past and the 10:00 slot was already attempted
+	if err := store.RecordUse(QueueKey("tips"), "a", monday.AddDate(0, 0, -3)); err != nil {
+		t.Fatal(err)
+	}
+	slot := queue.Post(config.QueueItem{}, monday.Add(2*time.Hour))
+	if err := store.Begin(state.Record{Key: slot.ID}, monday); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := NewExecutor(store).queuedPosts(&config.Config{Queues: []config.Queue{queue}}, monday)
+	if len(posts) != 1 {
+		t.Fatalf("queuedPosts() = %d posts, want 1", len(posts))
+	}
+	post := posts[0]
+	if post.ID != "tips@20240610T1500" || post.Content != "Tip B" || post.Queue.Item != "b" {
+		t.Errorf("queuedPosts() = %s %q (item %s), want tips@20240610T1500 with item b", post.ID, post.Content, post.Queue.Item)
+	}
+}
+
+func TestExecutor_QueuedPostsMidDay(t *testing.T) {
+	queue := config.Queue{
+		Name:    "tips",
+		Enabled: true,
+		Slots:   []config.QueueSlot{{At: "09:00"}, {At: "12:00"}, {At: "18:00"}},
+		Items:   []config.QueueItem{{ID: "a", Content: "Tip A"}, {ID: "b", Content: "Tip B"}, {ID: "c", Content: "Tip C"}},
+	}
+	afternoon := time.Date(2024, 6, 10, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		missed *config.MissedPolicy
+		want   []string
+	}{
+		{
+			name: "missed slots take no items",
+			want: []string{"tips@20240610T1800 a"},
+		},
+		{
+			name:   "caught up slots take items",
+			missed: &config.MissedPolicy{Action: config.MissedPostIfWithin, Within: 4 * time.Hour},
+			want:   []string{"tips@20240610T1200 a", "tips@20240610T1800 b"},
+		},
+		{
+			name:   "post now catches up every slot",
+			missed: &config.MissedPolicy{Action: config.MissedPostNow},
+			want:   []string{"tips@20240610T0900 a", "tips@20240610T1200 b", "tips@20240610T1800 c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config.Config{Missed: tt.missed, Queues: []config.Queue{queue}}
+
+			var got []string
+			for _, post := range NewExecutor(store).queuedPosts(cfg, afternoon) {
+				got = append(got, post.ID+" "+post.Queue.Item)
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("queuedPosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
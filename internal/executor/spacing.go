@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+)
+
+// Keeps posts on the same account at least the minimum gap apart. Under
+// the shift policy, later posts are pushed forward, and posts that would be
+// pushed past the end of their day are suppressed; under the fail policy,
+// later posts are suppressed. Returns the kept posts sorted by execution time.
+func ApplySpacing(posts []ScheduledPost, gapFor func(account string) time.Duration,
+	policy config.SpacingPolicy) ([]ScheduledPost, []SuppressedPost) {
+	sorted := make([]ScheduledPost, len(posts))
+	copy(sorted, posts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ExecuteAt.Before(sorted[j].ExecuteAt)
+	})
+
+	var kept []ScheduledPost
+	var suppressed []SuppressedPost
+	last := make(map[string]ScheduledPost) // Previous kept post per account
+	for _, scheduledPost := range sorted {
+		// Test posts are executed immediately and are not spaced
+		if scheduledPost.Post.Test {
+			kept = append(kept, scheduledPost)
+			continue
+		}
+
+		account := scheduledPost.Post.Account
+		if prev, ok := last[account]; ok {
+			earliest := prev.ExecuteAt.Add(gapFor(account))
+			if scheduledPost.ExecuteAt.Before(earliest) {
+				if policy == config.SpacingFail {
+					suppressed = append(suppressed, SuppressedPost{
+						Post: scheduledPost.Post,
+						At:   scheduledPost.ExecuteAt,
+						Reason: fmt.Sprintf("spacing: %v after post %s (minimum gap %v, policy fail)",
+							scheduledPost.ExecuteAt.Sub(prev.ExecuteAt), prev.Post.Identifier(), gapFor(account)),
+					})
+					continue
+				}
+				if !sameDay(earliest, scheduledPost.ExecuteAt) {
+					suppressed = append(suppressed, SuppressedPost{
+						Post:   scheduledPost.Post,
+						At:     scheduledPost.ExecuteAt,
+						Reason: fmt.Sprintf("spacing: keeping %v after post %s leaves no slot today", gapFor(account), prev.Post.Identifier()),
+					})
+					continue
+				}
+				scheduledPost.Adjustments = append(scheduledPost.Adjustments, fmt.Sprintf("spacing: moved from %s to keep %v after post %s",
+					scheduledPost.ExecuteAt.Format("15:04:05"), gapFor(account), prev.Post.Identifier()))
+				scheduledPost.ExecuteAt = earliest
+			}
+		}
+		last[account] = scheduledPost
+		kept = append(kept, scheduledPost)
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].ExecuteAt.Before(kept[j].ExecuteAt)
+	})
+	return kept, suppressed
+}
@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+)
+
+func TestApplySpacing(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	gapFor := func(account string) time.Duration {
+		if account == "brand" {
+			return 10 * time.Minute
+		}
+		return 5 * time.Minute
+	}
+
+	posts := []ScheduledPost{
+		{Post: config.Post{ID: "a"}, ExecuteAt: at(9, 0)},
+		{Post: config.Post{ID: "b"}, ExecuteAt: at(9, 0)},
+		{Post: config.Post{ID: "c"}, ExecuteAt: at(9, 3)},
+		{Post: config.Post{ID: "d", Account: "brand"}, ExecuteAt: at(9, 1)},
+		{Post: config.Post{ID: "e", Account: "brand"}, ExecuteAt: at(9, 30)},
+		{Post: config.Post{ID: "test", Test: true}, ExecuteAt: at(9, 2)},
+	}
+
+	spaced, suppressed := ApplySpacing(posts, gapFor, config.SpacingShift)
+	if len(suppressed) != 0 {
+		t.Errorf("ApplySpacing() suppressed %v, want none", suppressed)
+	}
+
+	want := map[string]time.Time{
+		"a":    at(9, 0),
+		"b":    at(9, 5),
+		"c":    at(9, 10),
+		"d":    at(9, 1),
+		"e":    at(9, 30),
+		"test": at(9, 2),
+	}
+	for _, sp := range spaced {
+		if !sp.ExecuteAt.Equal(want[sp.Post.ID]) {
+			t.Errorf("post %s ExecuteAt = %s, want %s",
+				sp.Post.ID, sp.ExecuteAt.Format("15:04"), want[sp.Post.ID].Format("15:04"))
+		}
+		moved := !sp.ExecuteAt.Equal(posts[indexOf(posts, sp.Post.ID)].ExecuteAt)
+		if moved != (len(sp.Adjustments) > 0) {
+			t.Errorf("post %s Adjustments = %v, want adjustments only when moved", sp.Post.ID, sp.Adjustments)
+		}
+	}
+
+	for i := 1; i < len(spaced); i++ {
+		if spaced[i].ExecuteAt.Before(spaced[i-1].ExecuteAt) {
+			t.Errorf("ApplySpacing() result is not sorted at index %d", i)
+		}
+	}
+}
+
+func TestApplySpacing_EndOfDay(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	gapFor := func(account string) time.Duration { return 10 * time.Minute }
+
+	posts := []ScheduledPost{
+		{Post: config.Post{ID: "a"}, ExecuteAt: at(23, 40)},
+		{Post: config.Post{ID: "b"}, ExecuteAt: at(23, 42)},
+		{Post: config.Post{ID: "c"}, ExecuteAt: at(23, 44)},
+		{Post: config.Post{ID: "d"}, ExecuteAt: at(23, 46)},
+	}
+	kept, suppressed := ApplySpacing(posts, gapFor, config.SpacingShift)
+
+	var keptIDs, suppressedIDs []string
+	for _, sp := range kept {
+		keptIDs = append(keptIDs, sp.Post.ID+"@"+sp.ExecuteAt.Format("15:04"))
+	}
+	for _, sp := range suppressed {
+		suppressedIDs = append(suppressedIDs, sp.Post.ID)
+		if !strings.Contains(sp.Reason, "leaves no slot today") {
+			t.Errorf("post %s reason = %q, want no slot today", sp.Post.ID, sp.Reason)
+		}
+	}
+	if got := strings.Join(keptIDs, ","); got != "a@23:40,b@23:50" {
+		t.Errorf("ApplySpacing() kept %s, want a@23:40,b@23:50", got)
+	}
+	if got := strings.Join(suppressedIDs, ","); got != "c,d" {
+		t.Errorf("ApplySpacing() suppressed %s, want c,d", got)
+	}
+}
+
+func TestApplySpacing_FailPolicy(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	gapFor := func(account string) time.Duration { return 10 * time.Minute }
+
+	posts := []ScheduledPost{
+		{Post: config.Post{ID: "a"}, ExecuteAt: at(9, 0)},
+		{Post: config.Post{ID: "b"}, ExecuteAt: at(9, 4)},
+		{Post: config.Post{ID: "c"}, ExecuteAt: at(9, 12)},
+		{Post: config.Post{ID: "other", Account: "brand"}, ExecuteAt: at(9, 2)},
+	}
+	kept, suppressed := ApplySpacing(posts, gapFor, config.SpacingFail)
+
+	var keptIDs []string
+	for _, sp := range kept {
+		if !sp.ExecuteAt.Equal(posts[indexOf(posts, sp.Post.ID)].ExecuteAt) {
+			t.Errorf("post %s was moved to %s, want no shifts under the fail policy", sp.Post.ID, sp.ExecuteAt.Format("15:04"))
+		}
+		keptIDs = append(keptIDs, sp.Post.ID)
+	}
+	if got := strings.Join(keptIDs, ","); got != "a,other,c" {
+		t.Errorf("ApplySpacing() kept %s, want a,other,c", got)
+	}
+	want := "spacing: 4m0s after post a (minimum gap 10m0s, policy fail)"
+	if len(suppressed) != 1 || suppressed[0].Post.ID != "b" || suppressed[0].Reason != want {
+		t.Errorf("ApplySpacing() suppressed %v, want b with reason %q", suppressed, want)
+	}
+}
+
+func indexOf(posts []ScheduledPost, id string) int {
+	for i, sp := range posts {
+		if sp.Post.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+// Returns the key of the rotation history shared by posts with the same variants
+func RotationKey(variants []config.Variant) string {
+	var b strings.Builder
+	for _, variant := range variants {
+		b.WriteString(strconv.Itoa(variant.WeightOrDefault()) + ":" + variant.Content + "\n")
+	}
+	return hashKey(b.String())
+}
+
+// Returns the key a variant's last use is recorded under
+func VariantKey(variant config.Variant) string {
+	return hashKey(variant.Content)
+}
+
+// Returns a short hex digest of s
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Returns the index of the variant to post next given the rotation history
+//
+// Round robin cycles through the variants, repeating each as often as its
+// weight. Random picks by weight using seed, so that a dry run predicts the
+// real choice. Least recent picks the variant posted longest ago, preferring
+// variants never posted and then configuration order.
+func ChooseVariant(variants []config.Variant, rotation config.Rotation, history state.Rotation, seed int64) int {
+	total := 0
+	for _, variant := range variants {
+		total += variant.WeightOrDefault()
+	}
+
+	switch rotation {
+	case config.RotationRandom:
+		return pickWeighted(variants, rand.New(rand.NewSource(seed)).Intn(total))
+	case config.RotationLeastRecent:
+		best := 0
+		var bestAt time.Time
+		for i, variant := range variants {
+			at, used := history.LastUsed[VariantKey(variant)]
+			if !used {
+				return i
+			}
+			if i == 0 || at.Before(bestAt) {
+				best, bestAt = i, at
+			}
+		}
+		return best
+	}
+	return pickWeighted(variants, history.Selections%total)
+}
+
+// Returns the variant at position n of the variants repeated by weight
+func pickWeighted(variants []config.Variant, n int) int {
+	for i, variant := range variants {
+		n -= variant.WeightOrDefault()
+		if n < 0 {
+			return i
+		}
+	}
+	return len(variants) - 1
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+func TestChooseVariant(t *testing.T) {
+	variants := []config.Variant{
+		{Content: "Good morning!"},
+		{Content: "Morning, all!", Weight: 2},
+		{Content: "Rise and shine!"},
+	}
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	used := func(times ...time.Time) state.Rotation {
+		rotation := state.Rotation{Selections: len(times), LastUsed: make(map[string]time.Time)}
+		for i, t := range times {
+			if !t.IsZero() {
+				rotation.LastUsed[VariantKey(variants[i])] = t
+			}
+		}
+		return rotation
+	}
+
+	tests := []struct {
+		name     string
+		rotation config.Rotation
+		history  state.Rotation
+		want     int
+	}{
+		{"round robin starts with the first", config.RotationRoundRobin, state.Rotation{}, 0},
+		{"round robin repeats weighted variants", config.RotationRoundRobin, state.Rotation{Selections: 2}, 1},
+		{"round robin moves on", config.RotationRoundRobin, state.Rotation{Selections: 3}, 2},
+		{"round robin wraps around", config.RotationRoundRobin, state.Rotation{Selections: 4}, 0},
+		{"least recent prefers unused", config.RotationLeastRecent, used(at, at.Add(time.Hour)), 2},
+		{"least recent picks the oldest", config.RotationLeastRecent, used(at.Add(time.Hour), at, at.Add(2*time.Hour)), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChooseVariant(variants, tt.rotation, tt.history, 1); got != tt.want {
+				t.Errorf("ChooseVariant() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// Random choices follow the weights and are reproducible from the seed
+	counts := make([]int, len(variants))
+	for seed := int64(0); seed < 4000; seed++ {
+		choice := ChooseVariant(variants, config.RotationRandom, state.Rotation{}, seed)
+		if again := ChooseVariant(variants, config.RotationRandom, state.Rotation{}, seed); again != choice {
+			t.Fatalf("ChooseVariant() with seed %d = %d, then %d", seed, choice, again)
+		}
+		counts[choice]++
+	}
+	if counts[1] < counts[0]*3/2 || counts[1] < counts[2]*3/2 {
+		t.Errorf("random counts = %v, want the weighted variant about twice as often", counts)
+	}
+}
+
+func TestExecutor_PlanRotatesSharedVariants(t *testing.T) {
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants := []config.Variant{{Content: "Hello"}, {Content: "Hi"}, {Content: "Hey"}}
+	if err := store.RecordUse(RotationKey(variants), VariantKey(variants[0]), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	cfg := &config.Config{
+		Posts: []config.Post{
+			{ID: "first", Variants: variants, ScheduledAt: now.Add(time.Minute), Enabled: true},
+			{ID: "second", Variants: variants, ScheduledAt: now.Add(2 * time.Minute), Enabled: true},
+		},
+	}
+	if !IsToday(now.Add(2*time.Minute), now) {
+		t.Skip("test posts would fall on tomorrow")
+	}
+
+	planned, _ := NewExecutor(store).Plan(cfg)
+	var got []string
+	for _, scheduledPost := range planned {
+		got = append(got, scheduledPost.Content)
+	}
+	if len(got) != 2 || got[0] != "Hi" || got[1] != "Hey" {
+		t.Errorf("Plan() contents = %v, want [Hi Hey]", got)
+	}
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/executor"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+// Maximum length of a content line in octets, excluding the CRLF
+const maxLineOctets = 75
+
+// Maximum length of the event summary in characters
+const maxSummaryLen = 60
+
+// Returns the posts that belong in the exported schedule, ordered by time,
+// with their texts as they will be posted
+//
+// Enabled queues contribute their slots within the given number of days,
+// assigned the same way as at execution time given the progress in store
+// (nil for none).
+func PlannedPosts(cfg *config.Config, store *state.Store, now time.Time, days int) []config.Post {
+	var posts []config.Post
+	for _, post := range cfg.GetEnabledPosts() {
+		// Test posts run immediately and have no place in the calendar
+		if !post.Test {
+			posts = append(posts, post)
+		}
+	}
+	for _, queue := range cfg.Queues {
+		if queue.Enabled {
+			posts = append(posts, executor.QueuePosts(cfg, queue, store, now, days)...)
+		}
+	}
+	for i, post := range posts {
+		posts[i] = renderPost(cfg, post)
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].ScheduledAt.Before(posts[j].ScheduledAt)
+	})
+	return posts
+}
+
+// Returns the post with every text it may publish rendered at its scheduled
+// time, with templates filled in and links tagged
+//
+// A text whose template fails to render is kept as written.
+func renderPost(cfg *config.Config, post config.Post) config.Post {
+	if len(post.Variants) == 0 {
+		if text, err := cfg.RenderContent(post, post.ScheduledAt); err == nil {
+			post.Content = text
+		}
+		return post
+	}
+
+	variants := append([]config.Variant(nil), post.Variants...)
+	for i := range variants {
+		if text, err := cfg.RenderContent(post.WithVariant(i), post.ScheduledAt); err == nil {
+			variants[i].Content = text
+		}
+	}
+	post.Variants = variants
+	return post
+}
+
+// Writes the posts as an iCalendar feed with one event per post
+func WriteICS(w io.Writer, posts []config.Post, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//x-scheduler//Scheduled posts//EN")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "X-WR-CALNAME:Scheduled posts")
+
+	for _, post := range posts {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+escapeText(post.Identifier()))
+		writeLine(bw, "DTSTAMP:"+stamp)
+		writeLine(bw, "DTSTART:"+post.ScheduledAt.UTC().Format("20060102T150405Z"))
+		writeLine(bw, "SUMMARY:"+escapeText(summary(post)))
+		// Posts with variants list every text the post may be published with
+		writeLine(bw, "DESCRIPTION:"+escapeText(strings.Join(post.Contents(), "\n---\n")))
+		if post.Account != "" {
+			writeLine(bw, "CATEGORIES:"+escapeText(post.Account))
+		}
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// Returns a short event title from the first line of the content
+func summary(post config.Post) string {
+	line := strings.TrimSpace(strings.SplitN(post.Contents()[0], "\n", 2)[0])
+	if utf8.RuneCountInString(line) > maxSummaryLen {
+		runes := []rune(line)
+		line = string(runes[:maxSummaryLen-3]) + "..."
+	}
+	if post.Account != "" {
+		return fmt.Sprintf("@%s: %s", post.Account, line)
+	}
+	return line
+}
+
+// Writes a content line, folding it at 75 octets without splitting characters
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// Escapes a TEXT value
+func escapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,217 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+	"github.com/zinrai/x-scheduler/internal/executor"
+	"github.com/zinrai/x-scheduler/internal/state"
+)
+
+func TestPlannedPosts(t *testing.T) {
+	now := time.Now()
+	cfg := &config.Config{
+		Posts: []config.Post{
+			{ID: "later", Content: "later", ScheduledAt: now.Add(2 * time.Hour), Enabled: true},
+			{ID: "disabled", Content: "disabled", ScheduledAt: now.Add(time.Hour)},
+			{ID: "test", Content: "test", ScheduledAt: now, Enabled: true, Test: true},
+			{ID: "sooner", Content: "sooner", ScheduledAt: now.Add(time.Hour), Enabled: true},
+		},
+	}
+
+	var ids []string
+	for _, post := range PlannedPosts(cfg, nil, now, 0) {
+		ids = append(ids, post.ID)
+	}
+	if got, want := strings.Join(ids, ","), "sooner,later"; got != want {
+		t.Errorf("PlannedPosts() = %s, want %s", got, want)
+	}
+}
+
+func TestPlannedPosts_RenderedContent(t *testing.T) {
+	at := time.Date(2030, 6, 4, 9, 0, 0, 0, time.UTC)
+	cfg := &config.Config{
+		Vars: map[string]string{"event": "GopherCon"},
+		UTM:  &config.UTM{Source: "x", Campaign: "launch"},
+		Posts: []config.Post{
+			{ID: "template", Content: "{{.Vars.event}} on {{.ScheduledAt.Format \"Jan 2\"}}", Template: true, ScheduledAt: at, Enabled: true},
+			{ID: "link", Content: "Read https://example.com/post", ScheduledAt: at.Add(time.Hour), Enabled: true},
+			{
+				ID:          "variants",
+				Variants:    []config.Variant{{Content: "A {{.Vars.event}}"}, {Content: "B https://example.com"}},
+				Template:    true,
+				ScheduledAt: at.Add(2 * time.Hour),
+				Enabled:     true,
+			},
+		},
+	}
+
+	var got []string
+	for _, post := range PlannedPosts(cfg, nil, at, 0) {
+		got = append(got, post.ID+"="+strings.Join(post.Contents(), " | "))
+	}
+	want := []string{
+		"template=GopherCon on Jun 4",
+		"link=Read https://example.com/post?utm_source=x&utm_campaign=launch",
+		"variants=A GopherCon | B https://example.com?utm_source=x&utm_campaign=launch",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("PlannedPosts() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if cfg.Posts[2].Variants[0].Content != "A {{.Vars.event}}" {
+		t.Errorf("PlannedPosts() changed the variants of the config")
+	}
+}
+
+func TestPlannedPosts_Queues(t *testing.T) {
+	loc := time.FixedZone("JST", 9*3600)
+	now := time.Date(2030, 6, 3, 12, 0, 0, 0, loc) // A Monday
+	cfg := &config.Config{
+		Posts: []config.Post{
+			{ID: "launch", Content: "Launch", ScheduledAt: time.Date(2030, 6, 4, 12, 0, 0, 0, loc), Enabled: true},
+		},
+		Queues: []config.Queue{
+			{
+				Name:    "tips",
+				Enabled: true,
+				Slots:   []config.QueueSlot{{At: "10:00"}, {At: "18:00", Weekdays: []string{"tue"}}},
+				Items:   []config.QueueItem{{ID: "one", Content: "Tip one"}, {ID: "two", Content: "Tip two"}, {ID: "three", Content: "Tip three"}},
+			},
+			{
+				Name:  "paused",
+				Slots: []config.QueueSlot{{At: "09:00"}},
+				Items: []config.QueueItem{{Content: "Never exported"}},
+			},
+		},
+	}
+
+	// Tip one was posted already, so the upcoming slots start with tip two
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordUse(executor.QueueKey("tips"), "one", now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, post := range PlannedPosts(cfg, store, now, 3) {
+		got = append(got, post.ID+"="+post.Content)
+	}
+	// Today's 10:00 slot has passed, and the queue runs dry after tip three
+	want := []string{
+		"tips@20300604T1000=Tip two",
+		"launch=Launch",
+		"tips@20300604T1800=Tip three",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("PlannedPosts() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, PlannedPosts(cfg, store, now, 3), now); err != nil {
+		t.Fatalf("WriteICS() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "UID:tips@20300604T1800\r\nDTSTAMP:20300603T030000Z\r\nDTSTART:20300604T090000Z\r\n") {
+		t.Errorf("WriteICS() has no event for the queue slot:\n%s", buf.String())
+	}
+}
+
+func TestPlannedPosts_QueueSlotsAsPosted(t *testing.T) {
+	now := time.Date(2030, 6, 3, 15, 0, 0, 0, time.UTC) // A Monday afternoon
+	cfg := &config.Config{
+		Vars:   map[string]string{"docs": "https://example.com/docs"},
+		UTM:    &config.UTM{Source: "x"},
+		Missed: &config.MissedPolicy{Action: config.MissedPostIfWithin, Within: 4 * time.Hour},
+		Queues: []config.Queue{{
+			Name:    "tips",
+			Enabled: true,
+			Slots:   []config.QueueSlot{{At: "09:00"}, {At: "12:00"}, {At: "18:00"}},
+			Items: []config.QueueItem{
+				{ID: "one", Content: "Read {{.Vars.docs}}", Template: true},
+				{ID: "two", Content: "Tip two"},
+				{ID: "three", Content: "Tip three"},
+			},
+		}},
+	}
+
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 09:00 slot is missed and the 12:00 slot is caught up, as at execution
+	var got []string
+	for _, post := range PlannedPosts(cfg, store, now, 1) {
+		got = append(got, post.ID+"="+post.Content)
+	}
+	want := []string{
+		"tips@20300603T1200=Read https://example.com/docs?utm_source=x",
+		"tips@20300603T1800=Tip two",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("PlannedPosts() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var executed []string
+	for _, post := range executor.QueuePosts(cfg, cfg.Queues[0], store, now, 1) {
+		executed = append(executed, post.ID+"="+post.Queue.Item)
+	}
+	if got, want := strings.Join(executed, ","), "tips@20300603T1200=one,tips@20300603T1800=two"; got != want {
+		t.Errorf("QueuePosts() = %s, want %s", got, want)
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	scheduled := time.Date(2024, 6, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*3600))
+	long := strings.Repeat("長いお知らせ、", 30)
+	posts := []config.Post{
+		{ID: "launch", Account: "brand", Content: "Launch day; tell everyone, now!\nMore at https://example.com", ScheduledAt: scheduled},
+		{Content: long, ScheduledAt: scheduled.Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, posts, scheduled); err != nil {
+		t.Fatalf("WriteICS() error = %v", err)
+	}
+	output := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line exceeds %d octets: %q", maxLineOctets, line)
+		}
+	}
+	if !strings.Contains(output, "SUMMARY:@brand: Launch day\\; tell everyone\\, now!\r\n") {
+		t.Errorf("WriteICS() summary not escaped:\n%s", output)
+	}
+
+	// The feed must be readable by the config package's iCalendar reader
+	path := filepath.Join(t.TempDir(), "posts.ics")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, err := config.ReadCalendar(path, scheduled.Add(-time.Hour), scheduled.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadCalendar() error = %v", err)
+	}
+	if len(events) != len(posts) {
+		t.Fatalf("ReadCalendar() returned %d events, want %d", len(events), len(posts))
+	}
+	for i, event := range events {
+		post := posts[i]
+		if event.UID != post.Identifier() {
+			t.Errorf("event %d UID = %q, want %q", i, event.UID, post.Identifier())
+		}
+		if event.Description != post.Content {
+			t.Errorf("event %d description = %q, want %q", i, event.Description, post.Content)
+		}
+		if !event.Start.Equal(post.ScheduledAt) {
+			t.Errorf("event %d start = %v, want %v", i, event.Start, post.ScheduledAt)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package linkcheck checks that links respond, reporting broken links,
+// redirects and TLS problems.
+package linkcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of the Checker settings
+const (
+	DefaultTimeout     = 10 * time.Second
+	DefaultConcurrency = 4
+)
+
+// Represents the outcome of checking a link
+type Result struct {
+	URL      string
+	Status   int    // HTTP status of the response, 0 if the request failed
+	Location string // Target of a redirect response
+	Err      error  // Network or TLS error
+}
+
+// Reports whether the link responded with a 2xx status
+func (r Result) OK() bool {
+	return r.Err == nil && r.Status >= 200 && r.Status < 300
+}
+
+// Reports whether the link redirects elsewhere
+func (r Result) Redirect() bool {
+	return r.Err == nil && r.Status >= 300 && r.Status < 400
+}
+
+// Reports whether the request failed because of the TLS connection or certificate
+func (r Result) TLSError() bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(r.Err, &verifyErr) || errors.As(r.Err, &recordErr) ||
+		errors.As(r.Err, &authorityErr) || errors.As(r.Err, &hostnameErr) || errors.As(r.Err, &invalidErr)
+}
+
+// Returns a description of the outcome, e.g. "returned 404 Not Found"
+func (r Result) String() string {
+	switch {
+	case r.TLSError():
+		return fmt.Sprintf("TLS error: %v", unwrapURLError(r.Err))
+	case r.Err != nil:
+		return fmt.Sprintf("request failed: %v", unwrapURLError(r.Err))
+	case r.Redirect() && r.Location != "":
+		return fmt.Sprintf("redirects (%d) to %s", r.Status, r.Location)
+	}
+	return fmt.Sprintf("returned %d %s", r.Status, http.StatusText(r.Status))
+}
+
+// Returns the cause of a *url.Error, whose message repeats the method and URL
+func unwrapURLError(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+	return err
+}
+
+// Checks links with a HEAD request, falling back to GET for servers that
+// don't answer HEAD properly
+//
+// Redirects are reported rather than followed.
+type Checker struct {
+	Client      *http.Client  // Client to send requests with (default: http.DefaultClient)
+	Timeout     time.Duration // Time limit of each request (default: DefaultTimeout)
+	Concurrency int           // Maximum number of requests in flight (default: DefaultConcurrency)
+}
+
+// Returns the results for the given links, in the same order
+func (c *Checker) Check(ctx context.Context, urls []string) []Result {
+	client := http.DefaultClient
+	if c.Client != nil {
+		client = c.Client
+	}
+	// A copy, so that the caller's client keeps following redirects
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make([]Result, len(urls))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = c.check(ctx, &noRedirects, url)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Checks a single link
+func (c *Checker) check(ctx context.Context, client *http.Client, url string) Result {
+	result := c.request(ctx, client, http.MethodHead, url)
+	// Some servers reject or mishandle HEAD, so failures are confirmed with GET
+	if result.Err == nil && result.Status >= 400 {
+		result = c.request(ctx, client, http.MethodGet, url)
+	}
+	return result
+}
+
+// Sends one request and records its outcome
+func (c *Checker) request(ctx context.Context, client *http.Client, method, url string) Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := Result{URL: url}
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	req.Header.Set("User-Agent", "x-scheduler-linkcheck")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	// The body is not needed, only the status
+	resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	if location, err := resp.Location(); err == nil {
+		result.Location = location.String()
+	}
+	return result
+}
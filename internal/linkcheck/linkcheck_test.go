@@ -0,0 +1,100 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChecker_Check(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	// The untrusted certificate fails the handshake, which the server would log
+	tlsServer := httptest.NewUnstartedServer(mux)
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	checker := &Checker{Client: server.Client(), Timeout: 200 * time.Millisecond, Concurrency: 2}
+	urls := []string{
+		server.URL + "/ok",
+		server.URL + "/missing",
+		server.URL + "/moved",
+		server.URL + "/no-head",
+		server.URL + "/slow",
+		tlsServer.URL + "/ok",
+		server.URL + "/ok",
+		server.URL + "/ok",
+	}
+	results := checker.Check(context.Background(), urls)
+
+	tests := []struct {
+		index int
+		ok    bool
+		want  string
+	}{
+		{0, true, "returned 200 OK"},
+		{1, false, "returned 404 Not Found"},
+		{2, false, "redirects (301) to " + server.URL + "/ok"},
+		{3, true, "returned 200 OK"},
+		{4, false, "request failed: context deadline exceeded"},
+		{5, false, "TLS error: "},
+	}
+	for _, tt := range tests {
+		result := results[tt.index]
+		if result.URL != urls[tt.index] {
+			t.Errorf("result %d is for %s, want %s", tt.index, result.URL, urls[tt.index])
+		}
+		if result.OK() != tt.ok || !strings.HasPrefix(result.String(), tt.want) {
+			t.Errorf("%s: OK() = %v, String() = %q, want %v, %q", result.URL, result.OK(), result, tt.ok, tt.want)
+		}
+	}
+
+	if max := maxInFlight.Load(); max > 2 {
+		t.Errorf("%d requests in flight, want at most 2", max)
+	}
+
+	// The caller's client still follows redirects
+	resp, err := server.Client().Get(server.URL + "/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("client status after redirect = %d, want 200", resp.StatusCode)
+	}
+}
@@ -9,21 +9,24 @@ import (
 	"github.com/zinrai/x-scheduler/pkg/logger"
 )
 
-// Posts content to X using xurl command
-func Post(content string) error {
-	// Create JSON payload using safe marshaling
-	reqBody := struct {
-		Text string `json:"text"`
-	}{
-		Text: content,
-	}
+// Represents optional parameters of a post
+type Options struct {
+	Account string // xurl username to post as (empty uses the default account)
+	ReplyTo string // ID of the tweet to reply to, for posting threads
+}
 
-	jsonBytes, err := json.Marshal(reqBody)
+// Posts content to X using xurl command and returns the created tweet ID
+func Post(content string, opts Options) (string, error) {
+	jsonBytes, err := requestBody(content, opts)
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	cmd := exec.Command("xurl", "-X", "POST", "/2/tweets", "-d", string(jsonBytes))
+	args := []string{"-X", "POST", "/2/tweets", "-d", string(jsonBytes)}
+	if opts.Account != "" {
+		args = append([]string{"-u", opts.Account}, args...)
+	}
+	cmd := exec.Command("xurl", args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -35,11 +38,53 @@ func Post(content string) error {
 	if err := cmd.Run(); err != nil {
 		logger.Error("xurl command failed: %v", err)
 		logger.Error("xurl stderr: %s", stderr.String())
-		return fmt.Errorf("xurl failed: %w, stderr: %s", err, stderr.String())
+		return "", fmt.Errorf("xurl failed: %w, stderr: %s", err, stderr.String())
 	}
 
 	logger.Debug("xurl stdout: %s", stdout.String())
-	return nil
+
+	tweetID := parseTweetID(stdout.Bytes())
+	if tweetID == "" {
+		logger.Warn("Could not determine tweet ID from xurl output")
+	}
+	return tweetID, nil
+}
+
+// Returns the JSON payload of the create tweet request
+func requestBody(content string, opts Options) ([]byte, error) {
+	type reply struct {
+		InReplyToTweetID string `json:"in_reply_to_tweet_id"`
+	}
+	reqBody := struct {
+		Text  string `json:"text"`
+		Reply *reply `json:"reply,omitempty"`
+	}{
+		Text: content,
+	}
+	if opts.ReplyTo != "" {
+		reqBody.Reply = &reply{InReplyToTweetID: opts.ReplyTo}
+	}
+
+	// Safe marshaling escapes quotes and control characters in the content
+	return json.Marshal(reqBody)
+}
+
+// Extracts the created tweet ID from the xurl response
+func parseTweetID(output []byte) string {
+	start := bytes.IndexByte(output, '{')
+	if start < 0 {
+		return ""
+	}
+
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(output[start:])).Decode(&resp); err != nil {
+		return ""
+	}
+	return resp.Data.ID
 }
 
 // Checks if xurl command is available and working
@@ -1,15 +1,13 @@
 package poster
 
-import (
-	"encoding/json"
-	"testing"
-)
+import "testing"
 
-// Test JSON marshaling behavior used in Post function
+// Test the JSON payload sent by the Post function
 func TestJSONMarshaling(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
+		opts     Options
 		expected string
 	}{
 		{
@@ -52,18 +50,17 @@ func TestJSONMarshaling(t *testing.T) {
 			input:    "Line1\r\nLine2\tTab\b\f",
 			expected: `{"text":"Line1\r\nLine2\tTab\b\f"}`,
 		},
+		{
+			name:     "reply in a thread",
+			input:    "Part 2",
+			opts:     Options{ReplyTo: "1234567890"},
+			expected: `{"text":"Part 2","reply":{"in_reply_to_tweet_id":"1234567890"}}`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Test the same JSON marshaling logic used in Post function
-			reqBody := struct {
-				Text string `json:"text"`
-			}{
-				Text: tt.input,
-			}
-
-			jsonBytes, err := json.Marshal(reqBody)
+			jsonBytes, err := requestBody(tt.input, tt.opts)
 			if err != nil {
 				t.Errorf("JSON marshaling failed: %v", err)
 				return
@@ -76,3 +73,46 @@ func TestJSONMarshaling(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTweetID(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "compact response",
+			output: `{"data":{"id":"1234567890","text":"Hello"}}`,
+			want:   "1234567890",
+		},
+		{
+			name:   "indented response with trailing output",
+			output: "{\n  \"data\": {\n    \"id\": \"42\",\n    \"text\": \"Hi\"\n  }\n}\nDone\n",
+			want:   "42",
+		},
+		{
+			name:   "leading text before JSON",
+			output: "Response:\n{\"data\":{\"id\":\"7\"}}",
+			want:   "7",
+		},
+		{
+			name:   "no JSON",
+			output: "ok",
+			want:   "",
+		},
+		{
+			name:   "error response",
+			output: `{"errors":[{"message":"Forbidden"}]}`,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTweetID([]byte(tt.output))
+			if got != tt.want {
+				t.Errorf("parseTweetID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
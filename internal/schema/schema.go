@@ -0,0 +1,340 @@
+// Package schema generates the JSON Schema of the configuration format.
+//
+// Descriptions and enums are taken from the doc comments and constants in
+// the config package source, so the schema is generated at development time
+// and embedded in the binary.
+package schema
+
+//go:generate go run ../../cmd schema -generate ../config -o config.schema.json
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zinrai/x-scheduler/internal/config"
+)
+
+// The generated schema, kept in sync with the config types by a test
+//
+//go:embed config.schema.json
+var JSON []byte
+
+// Matches Go duration strings such as 90s, 1h30m or 2h
+const durationPattern = `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// Represents a JSON Schema (draft 2020-12) node
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Generates the schema of config.Config, reading docs from the config package source
+func Generate(sourceDir string) ([]byte, error) {
+	return generate(sourceDir, reflect.TypeOf(config.Config{}))
+}
+
+// Generates the schema of the struct type t
+func generate(sourceDir string, t reflect.Type) ([]byte, error) {
+	docs, err := readDocs(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{docs: docs, defs: make(map[string]*Schema)}
+	root := g.structSchema(t)
+	if g.err != nil {
+		return nil, g.err
+	}
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.Title = "x-scheduler configuration"
+	root.Defs = g.defs
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Fields of which exactly one must be set, by type name
+var alternatives = map[string][]string{
+	"Post": {"content", "content_file", "variants"},
+}
+
+// String fields that also accept a mapping of language tag to text, by Type.Field
+var localized = map[string]bool{
+	"Post.Content": true,
+}
+
+// Builds schemas from Go types
+type generator struct {
+	docs *docs
+	defs map[string]*Schema
+	err  error // First type that could not be described
+}
+
+// Returns the schema of a field or element type
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return &Schema{Type: "string", Pattern: durationPattern}
+	case reflect.TypeOf(config.MissedPolicy{}):
+		return g.define(t, g.missedPolicySchema)
+	case reflect.TypeOf(config.Variant{}):
+		return g.define(t, g.variantSchema)
+	}
+
+	if values, ok := g.docs.enums[t.Name()]; ok && t.Kind() == reflect.String {
+		return &Schema{Type: "string", Enum: values}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		zero := 0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.define(t, func() *Schema { return g.structSchema(t) })
+	}
+	if g.err == nil {
+		g.err = fmt.Errorf("unsupported type %s", t)
+	}
+	return &Schema{}
+}
+
+// Adds a named type to $defs and returns a reference to it
+func (g *generator) define(t reflect.Type, build func() *Schema) *Schema {
+	name := t.Name()
+	if _, ok := g.defs[name]; !ok {
+		// Reserve the name first so recursive types terminate
+		g.defs[name] = nil
+		def := build()
+		if def.Description == "" {
+			def.Description = g.docs.types[name]
+		}
+		g.defs[name] = def
+	}
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+// Returns the schema of a struct with one property per YAML field
+//
+// Fields without omitempty are required, except booleans, which default to false.
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Description:          g.docs.types[t.Name()],
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		// Inlined structs contribute their fields to the parent mapping
+		if field.Anonymous && len(tag) > 1 && tag[1] == "inline" {
+			inlined := g.structSchema(field.Type)
+			if g.err != nil {
+				return s
+			}
+			for name, property := range inlined.Properties {
+				s.Properties[name] = property
+			}
+			s.Required = append(s.Required, inlined.Required...)
+			continue
+		}
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		property := g.schemaFor(field.Type)
+		if g.err != nil {
+			g.err = fmt.Errorf("%s.%s: %w", t.Name(), field.Name, g.err)
+			return s
+		}
+		if localized[t.Name()+"."+field.Name] {
+			property = &Schema{OneOf: []*Schema{
+				property,
+				{Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			}}
+		}
+		if doc := g.docs.fields[t.Name()+"."+field.Name]; doc != "" {
+			property.Description = doc
+		}
+		s.Properties[name] = property
+
+		omitempty := len(tag) > 1 && tag[1] == "omitempty"
+		if !omitempty && field.Type.Kind() != reflect.Bool {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	for _, name := range alternatives[t.Name()] {
+		s.OneOf = append(s.OneOf, &Schema{Required: []string{name}})
+	}
+	return s
+}
+
+// Returns the schema of the scalar or mapping form of a missed policy
+func (g *generator) missedPolicySchema() *Schema {
+	return &Schema{
+		OneOf: []*Schema{
+			{Type: "string", Enum: []string{string(config.MissedSkip), string(config.MissedPostNow)}},
+			{
+				Type: "object",
+				Properties: map[string]*Schema{
+					string(config.MissedPostIfWithin): {Type: "string", Pattern: durationPattern},
+				},
+				Required:             []string{string(config.MissedPostIfWithin)},
+				AdditionalProperties: false,
+			},
+		},
+	}
+}
+
+// Returns the schema of the text or mapping form of a variant
+func (g *generator) variantSchema() *Schema {
+	mapping := g.structSchema(reflect.TypeOf(config.Variant{}))
+	mapping.Description = ""
+	mapping.Required = []string{"content"}
+	return &Schema{OneOf: []*Schema{{Type: "string"}, mapping}}
+}
+
+// Represents documentation extracted from Go source
+type docs struct {
+	types  map[string]string   // Type name to description
+	fields map[string]string   // Type.Field to description
+	enums  map[string][]string // Type name to constant values
+}
+
+// Parses the non-test Go files in dir for type docs, field comments and constants
+func readDocs(dir string) (*docs, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &docs{
+		types:  make(map[string]string),
+		fields: make(map[string]string),
+		enums:  make(map[string][]string),
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok {
+				d.addDecl(gen)
+			}
+		}
+	}
+
+	if len(d.types) == 0 {
+		return nil, fmt.Errorf("no type declarations found in %s", dir)
+	}
+	return d, nil
+}
+
+// Records the docs of a type or const declaration
+func (d *docs) addDecl(gen *ast.GenDecl) {
+	for _, spec := range gen.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			doc := spec.Doc
+			if doc == nil {
+				doc = gen.Doc
+			}
+			d.types[spec.Name.Name] = describe(doc)
+
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				for _, field := range st.Fields.List {
+					text := describe(field.Comment)
+					if text == "" {
+						text = describe(field.Doc)
+					}
+					for _, name := range field.Names {
+						d.fields[spec.Name.Name+"."+name.Name] = text
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			ident, ok := spec.Type.(*ast.Ident)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, value := range spec.Values {
+				if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if s, err := strconv.Unquote(lit.Value); err == nil {
+						d.enums[ident.Name] = append(d.enums[ident.Name], s)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Returns the first paragraph of a comment as a sentence
+func describe(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	text := strings.TrimSpace(strings.SplitN(group.Text(), "\n\n", 2)[0])
+	text = strings.Join(strings.Fields(text), " ")
+	if rest, ok := strings.CutPrefix(text, "Represents "); ok {
+		text = strings.ToUpper(rest[:1]) + rest[1:]
+	}
+	return text
+}
@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaUpToDate(t *testing.T) {
+	generated, err := Generate("../config")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !bytes.Equal(generated, JSON) {
+		t.Errorf("config.schema.json is out of date with the config types; run: go generate ./internal/schema")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	var root Schema
+	if err := json.Unmarshal(JSON, &root); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	post := root.Defs["Post"]
+	if post == nil {
+		t.Fatal("schema has no Post definition")
+	}
+	if got, want := post.Required, []string{"scheduled_at"}; !equal(got, want) {
+		t.Errorf("Post required = %v, want %v", got, want)
+	}
+	if len(post.OneOf) != 3 || post.OneOf[1].Required[0] != "content_file" {
+		t.Errorf("Post oneOf = %+v, want content, content_file or variants", post.OneOf)
+	}
+	if got := post.Properties["scheduled_at"].Format; got != "date-time" {
+		t.Errorf("scheduled_at format = %q, want date-time", got)
+	}
+	if got := post.Properties["content"].Description; got != "Text of the post, or a mapping of language tag to text" {
+		t.Errorf("content description = %q", got)
+	}
+	if forms := post.Properties["content"].OneOf; len(forms) != 2 || forms[0].Type != "string" || forms[1].Type != "object" {
+		t.Errorf("content oneOf = %+v, want text and language mapping forms", forms)
+	}
+	if got, want := root.Defs["Pause"].Properties["mode"].Enum, []string{"hold", "drop"}; !equal(got, want) {
+		t.Errorf("pause mode enum = %v, want %v", got, want)
+	}
+	if missed := root.Defs["MissedPolicy"]; missed == nil || len(missed.OneOf) != 2 {
+		t.Errorf("MissedPolicy = %+v, want scalar and mapping forms", missed)
+	}
+}
+
+func TestGenerate_UnsupportedType(t *testing.T) {
+	type Unsupported struct {
+		Name  string             `yaml:"name"`
+		Ratio map[string]float64 `yaml:"ratio"`
+	}
+
+	_, err := generate("../config", reflect.TypeOf(Unsupported{}))
+	if err == nil || err.Error() != "Unsupported.Ratio: unsupported type float64" {
+		t.Errorf("generate() error = %v, want Unsupported.Ratio: unsupported type float64", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
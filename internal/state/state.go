@@ -0,0 +1,416 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// Current version of the state file format
+const fileVersion = 1
+
+// Represents the result of a posting attempt
+type Outcome string
+
+const (
+	OutcomePending Outcome = "pending" // Attempt started but result not recorded
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailed  Outcome = "failed"
+	OutcomeDryRun  Outcome = "dry_run"
+)
+
+// Represents a single posting attempt
+type Attempt struct {
+	At      time.Time `json:"at"`
+	Outcome Outcome   `json:"outcome"`
+	TweetID string    `json:"tweet_id,omitempty"`
+	Replies []string  `json:"replies,omitempty"` // Further tweets of a thread, in order
+	Thread  string    `json:"thread,omitempty"`  // Key of the thread content, to resume a partly posted thread
+	Error   string    `json:"error,omitempty"`
+}
+
+// Returns the tweets created by the attempt, keeping the position of a
+// first tweet whose ID is unknown
+func (a Attempt) chain() []string {
+	if a.TweetID == "" && len(a.Replies) == 0 {
+		return nil
+	}
+	return append([]string{a.TweetID}, a.Replies...)
+}
+
+// Represents the recorded history of a single post
+type Record struct {
+	Key         string    `json:"key"`
+	Account     string    `json:"account,omitempty"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	Summary     string    `json:"summary"`
+	Attempts    []Attempt `json:"attempts"`
+}
+
+// Returns the most recent attempt
+func (r Record) LastAttempt() (Attempt, bool) {
+	if len(r.Attempts) == 0 {
+		return Attempt{}, false
+	}
+	return r.Attempts[len(r.Attempts)-1], true
+}
+
+// Reports whether any attempt was successful
+func (r Record) Posted() bool {
+	for _, attempt := range r.Attempts {
+		if attempt.Outcome == OutcomeSuccess {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the IDs of all tweets created by the attempts
+func (r Record) TweetIDs() []string {
+	var ids []string
+	for _, attempt := range r.Attempts {
+		if attempt.TweetID != "" {
+			ids = append(ids, attempt.TweetID)
+		}
+		ids = append(ids, attempt.Replies...)
+	}
+	return ids
+}
+
+// Returns the key of a thread the last attempts left partly posted and the
+// IDs of its posted parts, in order
+//
+// A failed attempt that resumed a thread only records the parts it posted,
+// so the parts are collected over the trailing failed attempts of the same
+// thread.
+func (r Record) PartialThread() (string, []string, bool) {
+	last, ok := r.LastAttempt()
+	if !ok || last.Outcome != OutcomeFailed || last.Thread == "" {
+		return "", nil, false
+	}
+
+	var ids []string
+	for i := len(r.Attempts) - 1; i >= 0; i-- {
+		attempt := r.Attempts[i]
+		if attempt.Outcome != OutcomeFailed || attempt.Thread != last.Thread {
+			break
+		}
+		ids = append(attempt.chain(), ids...)
+	}
+	if len(ids) == 0 {
+		return "", nil, false
+	}
+	return last.Thread, ids, true
+}
+
+// Represents which items of a rotation, such as the variants of a post or
+// the items of a queue, were posted
+type Rotation struct {
+	Selections int                  `json:"selections"`          // Number of items posted so far
+	LastUsed   map[string]time.Time `json:"last_used,omitempty"` // Item key to time it was last posted
+}
+
+// Returns a deep copy of the rotation
+func (r Rotation) Clone() Rotation {
+	clone := Rotation{Selections: r.Selections, LastUsed: make(map[string]time.Time, len(r.LastUsed))}
+	for key, at := range r.LastUsed {
+		clone.LastUsed[key] = at
+	}
+	return clone
+}
+
+// Records that the item was posted at the given time
+func (r *Rotation) Use(item string, at time.Time) {
+	if r.LastUsed == nil {
+		r.LastUsed = make(map[string]time.Time)
+	}
+	r.Selections++
+	r.LastUsed[item] = at
+}
+
+// On-disk representation of the state file
+type stateFile struct {
+	Version   int                  `json:"version"`
+	Records   map[string]*Record   `json:"records"`
+	Rotations map[string]*Rotation `json:"rotations,omitempty"`
+}
+
+// Persists posting attempts in a local JSON file
+type Store struct {
+	path      string
+	records   map[string]*Record
+	rotations map[string]*Rotation
+	locked    bool // Whether an Update is in progress
+}
+
+// Opens the state file, starting empty if it does not exist yet
+func Open(path string) (*Store, error) {
+	store := &Store{path: path}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Replaces the records and rotations with the contents of the state file
+func (s *Store) load() error {
+	s.records = make(map[string]*Record)
+	s.rotations = make(map[string]*Rotation)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse state file %s: %w", s.path, err)
+	}
+	if file.Version > fileVersion {
+		return fmt.Errorf("state file %s has unsupported version %d", s.path, file.Version)
+	}
+	for key, record := range file.Records {
+		record.Key = key
+		s.records[key] = record
+	}
+	for key, rotation := range file.Rotations {
+		s.rotations[key] = rotation
+	}
+
+	return nil
+}
+
+// Runs fn with the state file locked against other runs and reloaded from
+// disk, then saves the changes fn made
+//
+// Changes are applied to the latest contents of the file, so overlapping
+// runs do not drop each other's records. Calls made by fn, such as Begin,
+// join the update instead of locking again.
+func (s *Store) Update(fn func() error) error {
+	if s.locked {
+		return fn()
+	}
+
+	lock, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open state lock file: %w", err)
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock state file: %w", err)
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	s.locked = true
+	defer func() { s.locked = false }()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Returns the path of the state file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Returns the record for the given key
+func (s *Store) Get(key string) (Record, bool) {
+	record, ok := s.records[key]
+	if !ok {
+		return Record{}, false
+	}
+	return *record, true
+}
+
+// Returns all records sorted by scheduled time
+func (s *Store) Records() []Record {
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].ScheduledAt.Equal(records[j].ScheduledAt) {
+			return records[i].Key < records[j].Key
+		}
+		return records[i].ScheduledAt.Before(records[j].ScheduledAt)
+	})
+	return records
+}
+
+// Records the start of an attempt for the post described by meta and saves
+// it before posting, so that a crash during posting is not mistaken for a
+// missing attempt
+func (s *Store) Begin(meta Record, at time.Time) error {
+	return s.Update(func() error {
+		record, ok := s.records[meta.Key]
+		if !ok {
+			record = &Record{Key: meta.Key}
+			s.records[meta.Key] = record
+		}
+		record.Account = meta.Account
+		record.ScheduledAt = meta.ScheduledAt
+		record.Summary = meta.Summary
+		record.Attempts = append(record.Attempts, Attempt{At: at, Outcome: OutcomePending})
+		return nil
+	})
+}
+
+// Records the thread the attempt in progress is posting and the tweets it
+// created so far, and saves it, so that a thread failing partway can be
+// resumed instead of posted again
+func (s *Store) Progress(key, thread string, tweetIDs []string) error {
+	return s.Update(func() error {
+		attempt, err := s.current(key)
+		if err != nil {
+			return err
+		}
+		attempt.Thread = thread
+		attempt.TweetID, attempt.Replies = "", nil
+		if len(tweetIDs) > 0 {
+			attempt.TweetID = tweetIDs[0]
+			attempt.Replies = tweetIDs[1:]
+		}
+		return nil
+	})
+}
+
+// Records the outcome of the attempt started by Begin and saves it
+//
+// tweetIDs are the tweets created by the attempt, the first followed by the
+// replies of a thread.
+func (s *Store) Finish(key string, outcome Outcome, tweetIDs []string, postErr error) error {
+	return s.Update(func() error {
+		attempt, err := s.current(key)
+		if err != nil {
+			return err
+		}
+		attempt.Outcome = outcome
+		if len(tweetIDs) > 0 {
+			attempt.TweetID = tweetIDs[0]
+			attempt.Replies = tweetIDs[1:]
+		}
+		if postErr != nil {
+			attempt.Error = postErr.Error()
+		}
+		return nil
+	})
+}
+
+// Returns the last attempt of the record for the given key
+func (s *Store) current(key string) (*Attempt, error) {
+	record, ok := s.records[key]
+	if !ok || len(record.Attempts) == 0 {
+		return nil, fmt.Errorf("no attempt in progress for %s", key)
+	}
+	return &record.Attempts[len(record.Attempts)-1], nil
+}
+
+// Returns the times of successful attempts at or after since, by account
+func (s *Store) PostedSince(since time.Time) map[string][]time.Time {
+	posted := make(map[string][]time.Time)
+	for _, record := range s.records {
+		for _, attempt := range record.Attempts {
+			if attempt.Outcome == OutcomeSuccess && !attempt.At.Before(since) {
+				posted[record.Account] = append(posted[record.Account], attempt.At)
+			}
+		}
+	}
+	return posted
+}
+
+// Returns a copy of the rotation for the given key
+func (s *Store) Rotation(key string) Rotation {
+	rotation, ok := s.rotations[key]
+	if !ok {
+		return Rotation{}
+	}
+	return rotation.Clone()
+}
+
+// Records that an item of the rotation was posted and saves it
+func (s *Store) RecordUse(key, item string, at time.Time) error {
+	return s.Update(func() error {
+		rotation, ok := s.rotations[key]
+		if !ok {
+			rotation = &Rotation{}
+			s.rotations[key] = rotation
+		}
+		rotation.Use(item, at)
+		return nil
+	})
+}
+
+// Removes the record for the given key and saves it
+func (s *Store) Delete(key string) (bool, error) {
+	found := false
+	err := s.Update(func() error {
+		_, found = s.records[key]
+		delete(s.records, key)
+		return nil
+	})
+	return found, err
+}
+
+// Removes records matching the given predicate, saves it and returns how
+// many were removed
+func (s *Store) Prune(match func(Record) bool) (int, error) {
+	removed := 0
+	err := s.Update(func() error {
+		for key, record := range s.records {
+			if match(*record) {
+				delete(s.records, key)
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Writes the state file atomically
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(stateFile{
+		Version:   fileVersion,
+		Records:   s.records,
+		Rotations: s.rotations,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	return nil
+}
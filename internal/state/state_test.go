@@ -0,0 +1,387 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_AttemptLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	scheduledAt := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+
+	if err := store.Begin(Record{Key: "a", ScheduledAt: scheduledAt, Summary: "first"}, scheduledAt); err != nil {
+		t.Fatalf("Begin() unexpected error = %v", err)
+	}
+
+	// A pending attempt must already be persisted before the outcome is known
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	record, ok := reopened.Get("a")
+	if !ok {
+		t.Fatalf("Get() record not found after Begin()")
+	}
+	if last, _ := record.LastAttempt(); last.Outcome != OutcomePending {
+		t.Errorf("LastAttempt().Outcome = %v, want %v", last.Outcome, OutcomePending)
+	}
+
+	if err := store.Finish("a", OutcomeFailed, nil, errors.New("boom")); err != nil {
+		t.Fatalf("Finish() unexpected error = %v", err)
+	}
+	if err := store.Begin(Record{Key: "a", ScheduledAt: scheduledAt, Summary: "first"}, scheduledAt.Add(time.Minute)); err != nil {
+		t.Fatalf("Begin() unexpected error = %v", err)
+	}
+	if err := store.Finish("a", OutcomeSuccess, []string{"123"}, nil); err != nil {
+		t.Fatalf("Finish() unexpected error = %v", err)
+	}
+
+	reopened, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	record, _ = reopened.Get("a")
+	if len(record.Attempts) != 2 {
+		t.Fatalf("len(Attempts) = %d, want 2", len(record.Attempts))
+	}
+	if record.Attempts[0].Error != "boom" {
+		t.Errorf("Attempts[0].Error = %q, want %q", record.Attempts[0].Error, "boom")
+	}
+	if !record.Posted() {
+		t.Errorf("Posted() = false, want true")
+	}
+	if ids := record.TweetIDs(); len(ids) != 1 || ids[0] != "123" {
+		t.Errorf("TweetIDs() = %v, want [123]", ids)
+	}
+}
+
+func TestStore_FinishWithoutBegin(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+
+	if err := store.Finish("missing", OutcomeSuccess, nil, nil); err == nil {
+		t.Errorf("Finish() expected error but got nil")
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	for i, key := range []string{"old", "new"} {
+		at := base.Add(time.Duration(i) * 48 * time.Hour)
+		if err := store.Begin(Record{Key: key, ScheduledAt: at, Summary: key}, at); err != nil {
+			t.Fatalf("Begin() unexpected error = %v", err)
+		}
+		if err := store.Finish(key, OutcomeSuccess, nil, nil); err != nil {
+			t.Fatalf("Finish() unexpected error = %v", err)
+		}
+	}
+
+	cutoff := base.Add(24 * time.Hour)
+	removed, err := store.Prune(func(record Record) bool {
+		last, _ := record.LastAttempt()
+		return last.At.Before(cutoff)
+	})
+	if err != nil {
+		t.Fatalf("Prune() unexpected error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune() removed %d records, want 1", removed)
+	}
+
+	records := store.Records()
+	if len(records) != 1 || records[0].Key != "new" {
+		t.Errorf("Records() after Prune() = %v, want only 'new'", records)
+	}
+}
+
+func TestStore_PostedSince(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	attempts := []struct {
+		key     string
+		account string
+		at      time.Time
+		outcome Outcome
+	}{
+		{"yesterday", "", base.Add(-24 * time.Hour), OutcomeSuccess},
+		{"morning", "", base, OutcomeSuccess},
+		{"failed", "", base.Add(time.Hour), OutcomeFailed},
+		{"brand", "brand", base.Add(2 * time.Hour), OutcomeSuccess},
+	}
+	for _, a := range attempts {
+		if err := store.Begin(Record{Key: a.key, Account: a.account}, a.at); err != nil {
+			t.Fatalf("Begin() unexpected error = %v", err)
+		}
+		if err := store.Finish(a.key, a.outcome, nil, nil); err != nil {
+			t.Fatalf("Finish() unexpected error = %v", err)
+		}
+	}
+
+	posted := store.PostedSince(base)
+	if len(posted[""]) != 1 || !posted[""][0].Equal(base) {
+		t.Errorf("PostedSince()[default] = %v, want [%v]", posted[""], base)
+	}
+	if len(posted["brand"]) != 1 {
+		t.Errorf("PostedSince()[brand] = %v, want 1 entry", posted["brand"])
+	}
+}
+
+func TestStore_RecordUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	if rotation := store.Rotation("greetings"); rotation.Selections != 0 {
+		t.Errorf("Rotation() of unknown key = %+v, want empty", rotation)
+	}
+	if err := store.RecordUse("greetings", "a", at); err != nil {
+		t.Fatalf("RecordUse() unexpected error = %v", err)
+	}
+	if err := store.RecordUse("greetings", "b", at.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordUse() unexpected error = %v", err)
+	}
+
+	// Changes to the returned copy must not leak into the store
+	store.Rotation("greetings").LastUsed["a"] = time.Time{}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	rotation := reopened.Rotation("greetings")
+	if rotation.Selections != 2 {
+		t.Errorf("Selections = %d, want 2", rotation.Selections)
+	}
+	if !rotation.LastUsed["a"].Equal(at) || !rotation.LastUsed["b"].Equal(at.Add(time.Hour)) {
+		t.Errorf("LastUsed = %v, want a at %v and b an hour later", rotation.LastUsed, at)
+	}
+}
+
+func TestStore_FinishThread(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	if err := store.Begin(Record{Key: "thread", ScheduledAt: at}, at); err != nil {
+		t.Fatalf("Begin() unexpected error = %v", err)
+	}
+	if err := store.Finish("thread", OutcomeSuccess, []string{"1", "2", "3"}, nil); err != nil {
+		t.Fatalf("Finish() unexpected error = %v", err)
+	}
+
+	record, _ := store.Get("thread")
+	if last, _ := record.LastAttempt(); last.TweetID != "1" || len(last.Replies) != 2 {
+		t.Errorf("LastAttempt() = %+v, want tweet 1 with 2 replies", last)
+	}
+	if ids := record.TweetIDs(); len(ids) != 3 || ids[2] != "3" {
+		t.Errorf("TweetIDs() = %v, want [1 2 3]", ids)
+	}
+}
+
+func TestRecord_PartialThread(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts []Attempt
+		want     []string
+		wantOK   bool
+	}{
+		{
+			name:     "failed after two parts",
+			attempts: []Attempt{{Outcome: OutcomeFailed, Thread: "t", TweetID: "1", Replies: []string{"2"}}},
+			want:     []string{"1", "2"},
+			wantOK:   true,
+		},
+		{
+			name: "resumed attempts add up",
+			attempts: []Attempt{
+				{Outcome: OutcomeFailed, Thread: "t", TweetID: "1"},
+				{Outcome: OutcomeFailed, Thread: "t"},
+				{Outcome: OutcomeFailed, Thread: "t", TweetID: "2"},
+			},
+			want:   []string{"1", "2"},
+			wantOK: true,
+		},
+		{
+			name: "earlier successful run is not part of the thread",
+			attempts: []Attempt{
+				{Outcome: OutcomeSuccess, Thread: "t", TweetID: "1", Replies: []string{"2", "3"}},
+				{Outcome: OutcomeFailed, Thread: "t", TweetID: "4"},
+			},
+			want:   []string{"4"},
+			wantOK: true,
+		},
+		{
+			name:     "failed before the first part",
+			attempts: []Attempt{{Outcome: OutcomeFailed, Thread: "t"}},
+		},
+		{
+			name:     "not a thread",
+			attempts: []Attempt{{Outcome: OutcomeFailed}},
+		},
+		{
+			name:     "posted",
+			attempts: []Attempt{{Outcome: OutcomeSuccess, Thread: "t", TweetID: "1", Replies: []string{"2"}}},
+		},
+		{
+			name:     "unknown outcome",
+			attempts: []Attempt{{Outcome: OutcomePending, Thread: "t", TweetID: "1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thread, ids, ok := Record{Attempts: tt.attempts}.PartialThread()
+			if ok != tt.wantOK {
+				t.Fatalf("PartialThread() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && thread != "t" {
+				t.Errorf("PartialThread() thread = %q, want %q", thread, "t")
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("PartialThread() ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Progress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	if err := store.Progress("thread", "t", []string{"1"}); err == nil {
+		t.Errorf("Progress() without Begin expected error")
+	}
+	if err := store.Begin(Record{Key: "thread", ScheduledAt: at}, at); err != nil {
+		t.Fatalf("Begin() unexpected error = %v", err)
+	}
+	if err := store.Progress("thread", "t", []string{"1", "2"}); err != nil {
+		t.Fatalf("Progress() unexpected error = %v", err)
+	}
+
+	// A crash before Finish leaves the posted parts on disk
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	record, _ := reopened.Get("thread")
+	last, _ := record.LastAttempt()
+	if last.Outcome != OutcomePending || last.Thread != "t" || last.TweetID != "1" || len(last.Replies) != 1 {
+		t.Errorf("LastAttempt() = %+v, want pending thread t with tweets 1 and 2", last)
+	}
+}
+
+func TestStore_SharedPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	// Both runs start before either has recorded anything
+	first, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	second, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+
+	if err := first.Begin(Record{Key: "a", ScheduledAt: at}, at); err != nil {
+		t.Fatalf("Begin() unexpected error = %v", err)
+	}
+	if err := second.Begin(Record{Key: "b", ScheduledAt: at}, at); err != nil {
+		t.Fatalf("Begin() unexpected error = %v", err)
+	}
+	if err := first.Finish("a", OutcomeSuccess, []string{"1"}, nil); err != nil {
+		t.Fatalf("Finish() unexpected error = %v", err)
+	}
+
+	// Neither run drops the other's records
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	if records := reopened.Records(); len(records) != 2 {
+		t.Errorf("Records() = %v, want a and b", records)
+	}
+
+	// An update sees what the other run recorded since it opened the file
+	var seen bool
+	if err := second.Update(func() error {
+		record, ok := second.Get("a")
+		seen = ok && record.Posted()
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() unexpected error = %v", err)
+	}
+	if !seen {
+		t.Errorf("Update() did not see post a recorded by the other store")
+	}
+}
+
+func TestStore_UpdateExcludesOtherStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	// Each run checks for a record and begins an attempt if there is none;
+	// only one of them may begin
+	const runs = 8
+	began := make(chan bool, runs)
+	for i := 0; i < runs; i++ {
+		go func() {
+			store, err := Open(path)
+			if err != nil {
+				t.Errorf("Open() unexpected error = %v", err)
+				began <- false
+				return
+			}
+			begin := false
+			err = store.Update(func() error {
+				if _, ok := store.Get("a"); ok {
+					return nil
+				}
+				begin = true
+				return store.Begin(Record{Key: "a", ScheduledAt: at}, at)
+			})
+			if err != nil {
+				t.Errorf("Update() unexpected error = %v", err)
+			}
+			began <- begin
+		}()
+	}
+
+	count := 0
+	for i := 0; i < runs; i++ {
+		if <-began {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("%d runs began an attempt, want 1", count)
+	}
+}